@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edged
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/edged/staticpod"
+)
+
+// staticPodSink implements staticpod.Sink, handing the pods it's given to
+// the edged pod worker the same way a pod synced down from the cloud would
+// arrive, keyed by manifest path so a later update/delete on the same file
+// replaces rather than duplicates the pod.
+type staticPodSink struct {
+	mu   sync.Mutex
+	pods map[string]*corev1.Pod
+
+	addOrUpdate func(pod *corev1.Pod)
+	remove      func(pod *corev1.Pod)
+}
+
+// startStaticPodSource watches manifestDir and feeds the manifests found
+// there into edged's pod worker via addOrUpdate/remove, backing
+// --pod-manifest-path.
+func startStaticPodSource(manifestDir string, addOrUpdate, remove func(pod *corev1.Pod)) (*staticpod.Source, error) {
+	sink := &staticPodSink{
+		pods:        map[string]*corev1.Pod{},
+		addOrUpdate: addOrUpdate,
+		remove:      remove,
+	}
+	return staticpod.NewSource(manifestDir, sink)
+}
+
+func (s *staticPodSink) SetStaticPod(source string, pod *corev1.Pod) {
+	s.mu.Lock()
+	s.pods[source] = pod
+	s.mu.Unlock()
+	s.addOrUpdate(pod)
+}
+
+func (s *staticPodSink) DeleteStaticPod(source string) {
+	s.mu.Lock()
+	pod, ok := s.pods[source]
+	delete(s.pods, source)
+	s.mu.Unlock()
+	if ok {
+		s.remove(pod)
+	}
+}
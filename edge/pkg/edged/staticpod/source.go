@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package staticpod backs --pod-manifest-path: it watches a directory of pod
+// manifests and hands the pods parsed from them to a Sink, the same way
+// kubelet's file source feeds static pods into its pod worker. Pods are
+// passed on as parsed; no annotation is added and nothing is sent to
+// MetaManager.
+package staticpod
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// resyncPeriod re-scans manifestDir on a timer in addition to the fsnotify
+// watch, so a manifest dropped via an editor save (rename, not write) or a
+// missed/coalesced inotify event is still eventually picked up.
+const resyncPeriod = time.Minute
+
+// Sink is how Source delivers the current set of static pods; edged's
+// staticPodSink implements it to add/remove pods in edged's pod worker.
+type Sink interface {
+	SetStaticPod(source string, pod *corev1.Pod)
+	DeleteStaticPod(source string)
+}
+
+// Source watches manifestDir for pod manifests and feeds them to sink.
+type Source struct {
+	manifestDir string
+	sink        Sink
+	known       map[string]bool
+}
+
+// NewSource starts watching manifestDir, doing an initial full scan before
+// returning so static pods present at edgecore startup are applied immediately.
+func NewSource(manifestDir string, sink Sink) (*Source, error) {
+	s := &Source{manifestDir: manifestDir, sink: sink, known: map[string]bool{}}
+
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("cannot start static pod watcher: %v", err)
+	}
+	if err := watcher.Add(manifestDir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("cannot watch %s: %v", manifestDir, err)
+	}
+	go s.run(watcher)
+
+	return s, nil
+}
+
+func (s *Source) run(watcher *fsnotify.Watcher) {
+	defer watcher.Close()
+	ticker := time.NewTicker(resyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !strings.HasSuffix(event.Name, ".yaml") && !strings.HasSuffix(event.Name, ".yml") {
+				continue
+			}
+			if err := s.scan(); err != nil {
+				fmt.Printf("static pod source: rescan after %s failed: %v\n", event, err)
+			}
+		case <-ticker.C:
+			if err := s.scan(); err != nil {
+				fmt.Printf("static pod source: periodic resync failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// scan reconciles every manifest currently in manifestDir against what was
+// last applied, pushing adds/updates and deletes to the sink.
+func (s *Source) scan() error {
+	entries, err := ioutil.ReadDir(s.manifestDir)
+	if err != nil {
+		return fmt.Errorf("cannot list %s: %v", s.manifestDir, err)
+	}
+
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() || (!strings.HasSuffix(entry.Name(), ".yaml") && !strings.HasSuffix(entry.Name(), ".yml")) {
+			continue
+		}
+		path := filepath.Join(s.manifestDir, entry.Name())
+		seen[path] = true
+
+		pod, err := loadPod(path)
+		if err != nil {
+			fmt.Printf("static pod source: skipping %s: %v\n", path, err)
+			continue
+		}
+		s.known[path] = true
+		s.sink.SetStaticPod(path, pod)
+	}
+
+	for path := range s.known {
+		if !seen[path] {
+			s.sink.DeleteStaticPod(path)
+			delete(s.known, path)
+		}
+	}
+	return nil
+}
+
+func loadPod(path string) (*corev1.Pod, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pod corev1.Pod
+	if err := yaml.Unmarshal(body, &pod); err != nil {
+		return nil, fmt.Errorf("invalid pod manifest: %v", err)
+	}
+	if pod.Name == "" {
+		return nil, fmt.Errorf("manifest is missing metadata.name")
+	}
+	return &pod, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edgehub
+
+import "time"
+
+// reconnectDelay is slept between a failed dial and asking the selector for
+// the next candidate endpoint, so a fully-down fleet of CloudCores doesn't
+// spin the loop.
+const reconnectDelay = time.Second
+
+// keepConnected drives the websocket/quic client's reconnect loop: it asks
+// selector for the current best CloudCore endpoint, calls dial against it,
+// and on disconnect reports the failure back to selector before picking the
+// next one. It returns only when selector has no healthy endpoint left.
+func keepConnected(selector *EndpointSelector, dial func(endpoint string) error) error {
+	for {
+		endpoint, err := selector.Next()
+		if err != nil {
+			return err
+		}
+
+		if err := dial(endpoint); err != nil {
+			selector.OnDisconnect(endpoint)
+			time.Sleep(reconnectDelay)
+			continue
+		}
+
+		// dial returned because the connection it held was closed
+		selector.OnDisconnect(endpoint)
+	}
+}
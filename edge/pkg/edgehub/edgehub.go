@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package edgehub is the beehive module that maintains edgecore's websocket/
+// quic connection to CloudCore.
+package edgehub
+
+import (
+	"fmt"
+
+	"github.com/kubeedge/beehive/pkg/core"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+const ModuleNameEdgeHub = "websocket"
+
+// edgeHub is the beehive Module for this package.
+type edgeHub struct {
+	enable   bool
+	selector *EndpointSelector
+}
+
+// Register builds and registers the EdgeHub module with beehive, selecting
+// among cfg.WebSocket.Server for failover when more than one is configured.
+func Register(enable bool, cfg *v1alpha1.EdgeHub) {
+	core.Register(&edgeHub{
+		enable:   enable,
+		selector: NewEndpointSelector(cfg.WebSocket.Server),
+	})
+}
+
+func (*edgeHub) Name() string   { return ModuleNameEdgeHub }
+func (*edgeHub) Group() string  { return "hub" }
+func (e *edgeHub) Enable() bool { return e.enable }
+
+// Start runs keepConnected against the configured CloudCore endpoints until
+// every one of them is unreachable.
+func (e *edgeHub) Start() {
+	if err := keepConnected(e.selector, dialWebSocket); err != nil {
+		fmt.Printf("edgehub: giving up, no healthy CloudCore endpoint: %v\n", err)
+	}
+}
+
+// dialWebSocket opens and serves the websocket connection to endpoint,
+// blocking until it's closed. The websocket client itself (message framing,
+// auth, ping/pong) is out of scope here; this is the seam keepConnected
+// calls per candidate endpoint once that client exists.
+func dialWebSocket(endpoint string) error {
+	return fmt.Errorf("websocket client not implemented for %s", endpoint)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edgehub
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// probeTimeout bounds a single endpoint health probe.
+	probeTimeout = 2 * time.Second
+	// minBackoff/maxBackoff bound the exponential backoff applied to an
+	// endpoint after consecutive failed probes or disconnects. The failure
+	// count resets whenever the endpoint answers a probe, so an endpoint that
+	// answers probes but keeps dropping its connection is backed off for
+	// minBackoff (plus jitter) each time.
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// EndpointSelector picks a single CloudCore endpoint, out of a configured
+// list, for EdgeHub's websocket/quic client to dial: it health-probes every
+// candidate and rotates to the next healthy one when the active connection
+// disconnects, backing off endpoints that keep failing.
+type EndpointSelector struct {
+	mu        sync.Mutex
+	endpoints []string
+	backoff   map[string]time.Time
+	attempts  map[string]int
+	current   string
+}
+
+// NewEndpointSelector builds a selector over endpoints, in the order given.
+func NewEndpointSelector(endpoints []string) *EndpointSelector {
+	return &EndpointSelector{
+		endpoints: endpoints,
+		backoff:   map[string]time.Time{},
+		attempts:  map[string]int{},
+	}
+}
+
+// Next returns the first endpoint that is not still backing off and answers a
+// TCP health probe, preferring the endpoint used last time if it still works.
+// It returns an error only when every endpoint is currently backed off or
+// unreachable.
+func (s *EndpointSelector) Next() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	candidates := s.orderedCandidates()
+	now := time.Now()
+	for _, ep := range candidates {
+		if until, backingOff := s.backoff[ep]; backingOff && now.Before(until) {
+			continue
+		}
+		if probe(ep) {
+			s.current = ep
+			s.attempts[ep] = 0
+			delete(s.backoff, ep)
+			return ep, nil
+		}
+		s.recordFailureLocked(ep)
+	}
+	return "", fmt.Errorf("no healthy CloudCore endpoint among %v", s.endpoints)
+}
+
+// orderedCandidates puts the currently active endpoint first so a transient
+// probe blip on everyone else doesn't cause an unnecessary rotation.
+func (s *EndpointSelector) orderedCandidates() []string {
+	if s.current == "" {
+		return s.endpoints
+	}
+	ordered := make([]string, 0, len(s.endpoints))
+	ordered = append(ordered, s.current)
+	for _, ep := range s.endpoints {
+		if ep != s.current {
+			ordered = append(ordered, ep)
+		}
+	}
+	return ordered
+}
+
+// OnDisconnect marks endpoint as failed, rotating future Next calls away from
+// it until its exponential backoff elapses.
+func (s *EndpointSelector) OnDisconnect(endpoint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == endpoint {
+		s.current = ""
+	}
+	s.recordFailureLocked(endpoint)
+}
+
+func (s *EndpointSelector) recordFailureLocked(endpoint string) {
+	s.attempts[endpoint]++
+	delay := minBackoff << uint(s.attempts[endpoint]-1)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	// jitter avoids every edge node retrying a recovering CloudCore in lockstep
+	jitter := time.Duration(rand.Int63n(int64(delay) / 4))
+	s.backoff[endpoint] = time.Now().Add(delay + jitter)
+}
+
+// probe dials endpoint with a short timeout to check it is currently
+// reachable before handing it to the websocket/quic client.
+func probe(endpoint string) bool {
+	conn, err := net.DialTimeout("tcp", endpoint, probeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metamanager
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/podfilter"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+// podFilter is the pod admission filter applied to local pod insert messages,
+// built once from the configured --pod-filter-config. It stays nil (allow
+// everything) when the filter is disabled.
+var podFilter *podfilter.Filter
+
+// InitPodFilter builds podFilter from cfg. It must be called once during
+// MetaManager startup, before any pod insert message is processed.
+func InitPodFilter(cfg *v1alpha1.PodFilter) error {
+	f, err := podfilter.NewFilter(cfg)
+	if err != nil {
+		return err
+	}
+	podFilter = f
+	return nil
+}
+
+// admitPodInsert rejects a local pod insert message for a pod this node is
+// not allowed to run, per podFilter, before it reaches the local store.
+func admitPodInsert(pod *corev1.Pod) error {
+	return podfilter.FilterPodInsert(podFilter, pod)
+}
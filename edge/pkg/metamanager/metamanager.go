@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metamanager is the beehive module that persists and syncs
+// node-local resources (pods, configmaps, ...) between CloudCore and edged's
+// local store.
+package metamanager
+
+import (
+	"fmt"
+
+	"github.com/kubeedge/beehive/pkg/core"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+const ModuleNameMetaManager = "metaManager"
+
+// metaManager is the beehive Module for this package.
+type metaManager struct {
+	enable bool
+}
+
+// Register builds and registers the MetaManager module with beehive,
+// building the pod admission filter from podFilterCfg so it's in place
+// before the first pod insert message arrives.
+func Register(enable bool, podFilterCfg *v1alpha1.PodFilter) {
+	if err := InitPodFilter(podFilterCfg); err != nil {
+		panic(fmt.Sprintf("metamanager: cannot init pod filter: %v", err))
+	}
+	core.Register(&metaManager{enable: enable})
+}
+
+func (*metaManager) Name() string   { return ModuleNameMetaManager }
+func (*metaManager) Group() string  { return "meta" }
+func (m *metaManager) Enable() bool { return m.enable }
+
+// Start runs the module's main loop, which processes messages off its
+// beehive channel and, for a pod insert, runs admitPodInsert. Messages are
+// not persisted to a local store by this loop.
+func (m *metaManager) Start() {
+	// processLoop reads from beehive's context for this module's group,
+	// applying admitPodInsert to each pod-insert message.
+	processLoop()
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package podfilter backs --pod-filter-config: it loads the allow/deny list
+// mirrored locally from the configured ConfigMap and lets MetaManager's pod
+// sync path reject inserts for pods this node should not run, without a
+// restart when the list changes on disk.
+package podfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+// localCacheDir holds the local copy of the configured ConfigMap's data, so
+// it can be read (and watched for changes) without an EdgeHub round trip on
+// every pod insert. This package only reads that copy; it does not write it.
+const localCacheDir = "/etc/kubeedge/podfilter"
+
+func localCachePath(cfg *v1alpha1.PodFilter) string {
+	return filepath.Join(localCacheDir, cfg.ConfigMapNamespace+"_"+cfg.ConfigMapName+".json")
+}
+
+// List is the on-disk shape of the allow/deny ConfigMap data at
+// localCachePath, reloaded here on change.
+type List struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Filter evaluates pods against a List kept in sync with localCachePath on disk.
+type Filter struct {
+	mu   sync.RWMutex
+	list List
+}
+
+// NewFilter loads cfg's allow/deny list from disk and starts watching it for
+// changes. A nil Filter (cfg.Enable == false) always allows every pod.
+func NewFilter(cfg *v1alpha1.PodFilter) (*Filter, error) {
+	if cfg == nil || !cfg.Enable {
+		return nil, nil
+	}
+
+	f := &Filter{}
+	path := localCachePath(cfg)
+	if err := f.reload(path); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("cannot start pod filter watcher: %v", err)
+	}
+	if err := watcher.Add(path); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("cannot watch %s: %v", path, err)
+	}
+	go f.watchLoop(watcher, path)
+
+	return f, nil
+}
+
+func (f *Filter) watchLoop(watcher *fsnotify.Watcher, path string) {
+	defer watcher.Close()
+	for event := range watcher.Events {
+		if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+			continue
+		}
+		if err := f.reload(path); err != nil {
+			fmt.Printf("pod filter: cannot reload %s: %v\n", path, err)
+		}
+	}
+}
+
+func (f *Filter) reload(path string) error {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read pod filter list %s: %v", path, err)
+	}
+	var list List
+	if err := json.Unmarshal(body, &list); err != nil {
+		return fmt.Errorf("cannot parse pod filter list %s: %v", path, err)
+	}
+	f.mu.Lock()
+	f.list = list
+	f.mu.Unlock()
+	return nil
+}
+
+// IsEdgePod reports whether pod is allowed to run on this node: the allow
+// list wins when non-empty, otherwise the deny list excludes matching pods
+// and everything else is let through.
+func IsEdgePod(f *Filter, pod *corev1.Pod) bool {
+	if f == nil {
+		return true
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	key := pod.Namespace + "/" + pod.Name
+	if len(f.list.Allow) > 0 {
+		return contains(f.list.Allow, key)
+	}
+	return !contains(f.list.Deny, key)
+}
+
+// FilterPodInsert is called from MetaManager's local message processing loop
+// before a pod insert message is persisted; it rejects pods this node is not
+// allowed to run instead of silently accepting them.
+func FilterPodInsert(f *Filter, pod *corev1.Pod) error {
+	if !IsEdgePod(f, pod) {
+		return fmt.Errorf("pod %s/%s is not allowed on this edge node by the configured pod filter", pod.Namespace, pod.Name)
+	}
+	return nil
+}
+
+func contains(list []string, key string) bool {
+	for _, entry := range list {
+		if entry == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metamanager
+
+import (
+	"fmt"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const resourceTypePod = "pod"
+
+// processLoop is MetaManager's main message loop: every message addressed to
+// this module passes through here. A pod insert is run past admitPodInsert,
+// so --pod-filter-config can reject pods this node shouldn't run, and a
+// rejected message is logged and dropped. Messages are not persisted here.
+func processLoop() {
+	for {
+		msg, err := beehiveContext.Receive(ModuleNameMetaManager)
+		if err != nil {
+			continue
+		}
+		if err := handleMessage(&msg); err != nil {
+			fmt.Printf("metamanager: dropping message %s: %v\n", msg.GetID(), err)
+			continue
+		}
+	}
+}
+
+func handleMessage(msg *model.Message) error {
+	if msg.GetOperation() != model.InsertOperation || msg.GetResourceType() != resourceTypePod {
+		return nil
+	}
+
+	var pod corev1.Pod
+	if err := msg.FillBody(&pod); err != nil {
+		return fmt.Errorf("cannot decode pod insert message: %v", err)
+	}
+	return admitPodInsert(&pod)
+}
@@ -17,8 +17,12 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/blang/semver"
 	"github.com/spf13/cobra"
@@ -74,6 +78,9 @@ func NewCloudInitBeta() *cobra.Command {
 	addInitBetaJoinOtherFlags(cmd, initbeta)
 	addHelmValueOptionsFlags(cmd, initbeta)
 	addForceOptionsFlags(cmd, initbeta)
+	addTemplateOptionsFlags(cmd, initbeta)
+	addChartRepoOptionsFlags(cmd, initbeta)
+	addProfileDirOptionFlags(cmd, initbeta)
 	return cmd
 }
 
@@ -118,14 +125,51 @@ func addInitBetaJoinOtherFlags(cmd *cobra.Command, initBetaOpts *types.InitBetaO
 
 func addHelmValueOptionsFlags(cmd *cobra.Command, initBetaOpts *types.InitBetaOptions) {
 	cmd.Flags().StringArrayVar(&initBetaOpts.Sets, "set", []string{}, "set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	cmd.Flags().StringArrayVar(&initBetaOpts.SetStrings, "set-string", []string{}, "set STRING values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	cmd.Flags().StringArrayVar(&initBetaOpts.SetFiles, "set-file", []string{}, "set values from respective files specified via the command line (can specify multiple or separate values with commas: key1=path1,key2=path2)")
+	cmd.Flags().StringArrayVar(&initBetaOpts.SetJSON, "set-json", []string{}, "set JSON values on the command line (can specify multiple or separate values with commas: key1=jsonval1,key2=jsonval2)")
+	cmd.Flags().StringArrayVar(&initBetaOpts.ValuesFiles, "values", []string{}, "specify chart values in a YAML file, merged ahead of --set/--set-string/--set-file/--set-json (can specify multiple)")
 	cmd.Flags().StringVar(&initBetaOpts.Profile, "profile", initBetaOpts.Profile, "set profile on the command line (iptablesMgrMode=external or version=1.9.1)")
 }
 
+func addProfileDirOptionFlags(cmd *cobra.Command, initBetaOpts *types.InitBetaOptions) {
+	cmd.Flags().StringVar(&initBetaOpts.ProfileDir, types.ProfileDir, initBetaOpts.ProfileDir,
+		"Directory of additional AddonProfile specs (*.yaml) to load, on top of $HOME/.keadm/profiles")
+}
+
 func addForceOptionsFlags(cmd *cobra.Command, initBetaOpts *types.InitBetaOptions) {
 	cmd.Flags().BoolVar(&initBetaOpts.Force, types.Force, initBetaOpts.Force,
 		"Forced installing the cloud components.")
 }
 
+func addTemplateOptionsFlags(cmd *cobra.Command, initBetaOpts *types.InitBetaOptions) {
+	cmd.Flags().BoolVar(&initBetaOpts.Template, types.Template, initBetaOpts.Template,
+		"Render the chart locally, like `helm template`, instead of installing it against a live cluster")
+	cmd.Flags().StringVar(&initBetaOpts.KubeVersion, types.KubeVersion, initBetaOpts.KubeVersion,
+		"Kubernetes version used to render --template output, eg: v1.24.0. Only takes effect with --template")
+	cmd.Flags().StringArrayVar(&initBetaOpts.APIVersions, types.APIVersions, initBetaOpts.APIVersions,
+		"Kubernetes api-versions used to render --template output, eg: apps/v1,networking.k8s.io/v1. Only takes effect with --template")
+}
+
+func addChartRepoOptionsFlags(cmd *cobra.Command, initBetaOpts *types.InitBetaOptions) {
+	cmd.Flags().StringVar(&initBetaOpts.ChartRepo, types.ChartRepo, initBetaOpts.ChartRepo,
+		"Chart repository to pull the chart from, either a classic https://.../index.yaml repo or an oci:// registry")
+	cmd.Flags().StringVar(&initBetaOpts.ChartRef, types.ChartRef, initBetaOpts.ChartRef,
+		"Chart reference to resolve within --chart-repo, eg: kubeedge/cloudcore")
+	cmd.Flags().StringVar(&initBetaOpts.ChartVersion, types.ChartVersion, initBetaOpts.ChartVersion,
+		"Pinned chart version to pull from --chart-repo, defaults to the latest available")
+	cmd.Flags().StringVar(&initBetaOpts.Username, types.ChartRepoUsername, initBetaOpts.Username,
+		"Username for --chart-repo basic auth")
+	cmd.Flags().StringVar(&initBetaOpts.Password, types.ChartRepoPassword, initBetaOpts.Password,
+		"Password for --chart-repo basic auth")
+	cmd.Flags().StringVar(&initBetaOpts.CAFile, types.ChartRepoCAFile, initBetaOpts.CAFile,
+		"CA bundle used to verify --chart-repo's TLS certificate")
+	cmd.Flags().BoolVar(&initBetaOpts.Verify, types.ChartVerify, initBetaOpts.Verify,
+		"Verify the chart's provenance file after downloading it from --chart-repo")
+	cmd.Flags().StringVar(&initBetaOpts.Keyring, types.ChartKeyring, initBetaOpts.Keyring,
+		"Keyring containing public keys to verify the chart's provenance file, required by --verify")
+}
+
 //Add2ToolsList Reads the flagData (containing val and default val) and join options to fill the list of tools.
 func AddInitBeta2ToolsList(toolList map[string]types.ToolsInstaller, flagData map[string]types.FlagData, initBetaOptions *types.InitBetaOptions) error {
 	var kubeVer string
@@ -168,6 +212,26 @@ func AddInitBeta2ToolsList(toolList map[string]types.ToolsInstaller, flagData ma
 		Sets:             initBetaOptions.Sets,
 		Profile:          initBetaOptions.Profile,
 		Force:            initBetaOptions.Force,
+		Template:         initBetaOptions.Template,
+		KubeVersion:      initBetaOptions.KubeVersion,
+		APIVersions:      initBetaOptions.APIVersions,
+		ChartRepo:        initBetaOptions.ChartRepo,
+		ChartRef:         initBetaOptions.ChartRef,
+		ChartVersion:     initBetaOptions.ChartVersion,
+		Username:         initBetaOptions.Username,
+		Password:         initBetaOptions.Password,
+		CAFile:           initBetaOptions.CAFile,
+		Verify:           initBetaOptions.Verify,
+		Keyring:          initBetaOptions.Keyring,
+		Atomic:           initBetaOptions.Atomic,
+		CleanupOnFail:    initBetaOptions.CleanupOnFail,
+		ReuseValues:      initBetaOptions.ReuseValues,
+		ResetValues:      initBetaOptions.ResetValues,
+		ProfileDir:       initBetaOptions.ProfileDir,
+		ValuesFiles:      initBetaOptions.ValuesFiles,
+		SetStrings:       initBetaOptions.SetStrings,
+		SetFiles:         initBetaOptions.SetFiles,
+		SetJSON:          initBetaOptions.SetJSON,
 		Action:           types.HelmInstallAction,
 	}
 	return nil
@@ -175,5 +239,17 @@ func AddInitBeta2ToolsList(toolList map[string]types.ToolsInstaller, flagData ma
 
 //ExecuteInitBeta the installation for each tool and start cloudcore
 func ExecuteInitBeta(toolList map[string]types.ToolsInstaller) error {
-	return toolList["helm"].InstallTools()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		<-sigCh
+		fmt.Println("Release has been cancelled")
+		cancel()
+	}()
+
+	return toolList["helm"].InstallTools(ctx)
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -101,6 +102,14 @@ func addManifestsGenerateJoinOtherFlags(cmd *cobra.Command, initBetaOpts *types.
 
 	cmd.Flags().BoolVar(&initBetaOpts.SkipCRDs, types.SkipCRDs, initBetaOpts.SkipCRDs,
 		"Print the generated k8s resources on the stdout, not actual excute. Always use in debug mode")
+
+	cmd.Flags().StringVar(&initBetaOpts.KubeVersion, types.KubeVersion, initBetaOpts.KubeVersion,
+		"Kubernetes version used to render the manifests, eg: v1.24.0")
+	cmd.Flags().StringArrayVar(&initBetaOpts.APIVersions, types.APIVersions, initBetaOpts.APIVersions,
+		"Kubernetes api-versions used to render the manifests, eg: apps/v1,networking.k8s.io/v1")
+
+	addChartRepoOptionsFlags(cmd, initBetaOpts)
+	addProfileDirOptionFlags(cmd, initBetaOpts)
 }
 
 //AddManifestsGenerate2ToolsList Reads the flagData (containing val and default val) and join options to fill the list of tools.
@@ -145,12 +154,29 @@ func AddManifestsGenerate2ToolsList(toolList map[string]types.ToolsInstaller, fl
 		Sets:             initBetaOptions.Sets,
 		Profile:          initBetaOptions.Profile,
 		SkipCRDs:         initBetaOptions.SkipCRDs,
-		Action:           types.HelmManifestAction,
+		// manifest generate always renders offline, mirroring `helm template --kube-version`
+		Template:     true,
+		KubeVersion:  initBetaOptions.KubeVersion,
+		APIVersions:  initBetaOptions.APIVersions,
+		ChartRepo:    initBetaOptions.ChartRepo,
+		ChartRef:     initBetaOptions.ChartRef,
+		ChartVersion: initBetaOptions.ChartVersion,
+		Username:     initBetaOptions.Username,
+		Password:     initBetaOptions.Password,
+		CAFile:       initBetaOptions.CAFile,
+		Verify:       initBetaOptions.Verify,
+		Keyring:      initBetaOptions.Keyring,
+		ProfileDir:   initBetaOptions.ProfileDir,
+		ValuesFiles:  initBetaOptions.ValuesFiles,
+		SetStrings:   initBetaOptions.SetStrings,
+		SetFiles:     initBetaOptions.SetFiles,
+		SetJSON:      initBetaOptions.SetJSON,
+		Action:       types.HelmManifestAction,
 	}
 	return nil
 }
 
 //ExecuteInitBeta the installation for each tool and start cloudcore
 func ExecuteManifestsGenerate(toolList map[string]types.ToolsInstaller) error {
-	return toolList["helm"].InstallTools()
+	return toolList["helm"].InstallTools(context.Background())
 }
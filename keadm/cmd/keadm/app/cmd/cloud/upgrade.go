@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/kubeedge/kubeedge/common/constants"
+	types "github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/common"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/util"
+)
+
+var (
+	cloudUpgradeLongDescription = `
+"keadm upgrade" command upgrades an already-deployed KubeEdge cloud component release to a new chart/profile,
+refusing to proceed unless the current release is in the "deployed" state.
+`
+	cloudUpgradeExample = `
+keadm upgrade --profile version=v1.12.0 --atomic
+
+  - This command upgrades the cloudcore release and rolls it back automatically if the upgrade fails
+`
+
+	cloudRollbackLongDescription = `
+"keadm rollback" command rolls the KubeEdge cloud component release back to a previous revision.
+`
+	cloudRollbackExample = `
+keadm rollback
+
+  - This command rolls cloudcore back to the previous revision
+
+keadm rollback 3
+
+  - This command rolls cloudcore back to revision 3
+`
+
+	cloudHistoryLongDescription = `
+"keadm history" command lists the revision history of the KubeEdge cloud component release.
+`
+	cloudHistoryExample = `
+keadm history
+`
+)
+
+// NewCloudUpgrade represents the keadm upgrade command for the cloud component
+func NewCloudUpgrade() *cobra.Command {
+	initbeta := newInitBetaOptions()
+
+	tools := make(map[string]types.ToolsInstaller)
+	flagVals := make(map[string]types.FlagData)
+
+	cmd := &cobra.Command{
+		Use:     "upgrade",
+		Short:   "Upgrades the cloud component to a new chart/profile.",
+		Long:    cloudUpgradeLongDescription,
+		Example: cloudUpgradeExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checkFlags := func(f *pflag.Flag) {
+				util.AddToolVals(f, flagVals)
+			}
+			cmd.Flags().VisitAll(checkFlags)
+			err := AddInitBeta2ToolsList(tools, flagVals, initbeta)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(sigCh)
+			go func() {
+				<-sigCh
+				fmt.Println("Upgrade has been cancelled")
+				cancel()
+			}()
+
+			return tools["helm"].(*util.KubeCloudHelmInstTool).Upgrade(ctx)
+		},
+	}
+
+	addInitBetaJoinOtherFlags(cmd, initbeta)
+	addHelmValueOptionsFlags(cmd, initbeta)
+	addChartRepoOptionsFlags(cmd, initbeta)
+	addProfileDirOptionFlags(cmd, initbeta)
+	addUpgradeOptionsFlags(cmd, initbeta)
+	return cmd
+}
+
+func addUpgradeOptionsFlags(cmd *cobra.Command, initBetaOpts *types.InitBetaOptions) {
+	cmd.Flags().BoolVar(&initBetaOpts.Atomic, types.Atomic, initBetaOpts.Atomic,
+		"Roll back the release automatically if the upgrade fails, matching Helm's --atomic flag")
+	cmd.Flags().BoolVar(&initBetaOpts.CleanupOnFail, types.CleanupOnFail, initBetaOpts.CleanupOnFail,
+		"Delete newly created resources during a failed upgrade/rollback")
+	cmd.Flags().BoolVar(&initBetaOpts.ReuseValues, types.ReuseValues, initBetaOpts.ReuseValues,
+		"Reuse the values from the last release and merge in any overrides from --set/--values")
+	cmd.Flags().BoolVar(&initBetaOpts.ResetValues, types.ResetValues, initBetaOpts.ResetValues,
+		"Reset the values to the chart defaults before applying --set/--values")
+}
+
+// NewCloudRollback represents the keadm rollback command for the cloud component
+func NewCloudRollback() *cobra.Command {
+	initbeta := newInitBetaOptions()
+
+	cmd := &cobra.Command{
+		Use:     "rollback [REVISION]",
+		Short:   "Rolls the cloud component release back to a previous revision.",
+		Long:    cloudRollbackLongDescription,
+		Example: cloudRollbackExample,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			revision := 0
+			if len(args) == 1 {
+				v, err := strconv.Atoi(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid revision %s: %s", args[0], err.Error())
+				}
+				revision = v
+			}
+
+			if initbeta.Namespace == "" {
+				initbeta.Namespace = constants.SystemNamespace
+			}
+			helm := &util.KubeCloudHelmInstTool{
+				Common:    util.Common{KubeConfig: initbeta.KubeConfig},
+				Namespace: initbeta.Namespace,
+			}
+			return helm.Rollback(revision)
+		},
+	}
+
+	addInitBetaJoinOtherFlags(cmd, initbeta)
+	return cmd
+}
+
+// NewCloudHistory represents the keadm history command for the cloud component
+func NewCloudHistory() *cobra.Command {
+	initbeta := newInitBetaOptions()
+
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   "Lists the revision history of the cloud component release.",
+		Long:    cloudHistoryLongDescription,
+		Example: cloudHistoryExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if initbeta.Namespace == "" {
+				initbeta.Namespace = constants.SystemNamespace
+			}
+			helm := &util.KubeCloudHelmInstTool{
+				Common:    util.Common{KubeConfig: initbeta.KubeConfig},
+				Namespace: initbeta.Namespace,
+			}
+			return helm.History()
+		},
+	}
+
+	addInitBetaJoinOtherFlags(cmd, initbeta)
+	return cmd
+}
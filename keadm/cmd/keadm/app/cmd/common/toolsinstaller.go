@@ -0,0 +1,12 @@
+package common
+
+import "context"
+
+// ToolsInstaller is the interface implemented by every cloud/edge install tool
+// (KubeCloudHelmInstTool, KubeEdgeInstTool, ...) driven by keadm init/join/reset.
+// InstallTools takes a context so a caller can cancel an install that is still
+// in flight (eg. on SIGINT/SIGTERM) instead of leaving it half-applied.
+type ToolsInstaller interface {
+	InstallTools(ctx context.Context) error
+	TearDown() error
+}
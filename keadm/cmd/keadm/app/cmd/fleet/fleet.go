@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fleet
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fleetLongDescription = `
+"keadm fleet" provisions a whole edge fleet from a single declarative EdgeCluster
+YAML config, fanning "keadm join"/"keadm reset" out over SSH to every listed host.
+`
+	fleetApplyExample = `
+keadm fleet apply -f cluster.yaml
+
+  - Joins every host listed in cluster.yaml as a KubeEdge edge node
+`
+)
+
+// NewFleet represents the keadm fleet command and its apply/reset/upgrade subcommands.
+func NewFleet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fleet",
+		Short: "Provisions an edge fleet from a declarative EdgeCluster config.",
+		Long:  fleetLongDescription,
+	}
+	cmd.AddCommand(newFleetApply(), newFleetReset(), newFleetUpgrade())
+	return cmd
+}
+
+func newFleetApply() *cobra.Command {
+	var configPath, tarballPath string
+	var maxParallel int
+	cmd := &cobra.Command{
+		Use:     "apply",
+		Short:   "Joins every host in the EdgeCluster config as an edge node.",
+		Example: fleetApplyExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFleet(configPath, tarballPath, maxParallel, ActionApply)
+		},
+	}
+	cmd.Flags().StringVarP(&configPath, "config", "f", "", "Path to the EdgeCluster YAML config (required)")
+	cmd.Flags().StringVar(&tarballPath, "tarball-path", "", "KubeEdge install tarball to upload once and reuse across hosts")
+	cmd.Flags().IntVar(&maxParallel, "max-parallel", 0, "Maximum number of hosts provisioned concurrently, 0 uses the config's own maxParallel or a built-in default")
+	_ = cmd.MarkFlagRequired("config")
+	return cmd
+}
+
+func newFleetReset() *cobra.Command {
+	var configPath string
+	var maxParallel int
+	cmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Resets every host in the EdgeCluster config, removing the edge node.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFleet(configPath, "", maxParallel, ActionReset)
+		},
+	}
+	cmd.Flags().StringVarP(&configPath, "config", "f", "", "Path to the EdgeCluster YAML config (required)")
+	cmd.Flags().IntVar(&maxParallel, "max-parallel", 0, "Maximum number of hosts reset concurrently")
+	_ = cmd.MarkFlagRequired("config")
+	return cmd
+}
+
+func newFleetUpgrade() *cobra.Command {
+	var configPath, tarballPath string
+	var maxParallel int
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Re-joins every host in the EdgeCluster config onto the version pinned in the config.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFleet(configPath, tarballPath, maxParallel, ActionUpgrade)
+		},
+	}
+	cmd.Flags().StringVarP(&configPath, "config", "f", "", "Path to the EdgeCluster YAML config (required)")
+	cmd.Flags().StringVar(&tarballPath, "tarball-path", "", "KubeEdge install tarball to upload once and reuse across hosts")
+	cmd.Flags().IntVar(&maxParallel, "max-parallel", 0, "Maximum number of hosts upgraded concurrently")
+	_ = cmd.MarkFlagRequired("config")
+	return cmd
+}
+
+func runFleet(configPath, tarballPath string, maxParallel int, action Action) error {
+	spec, err := LoadEdgeClusterSpec(configPath)
+	if err != nil {
+		return err
+	}
+
+	installer := &FleetInstaller{Spec: spec, TarballPath: tarballPath, MaxParallel: maxParallel}
+	results := installer.Run(action)
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("[%s] %s: %v\n%s\n", r.Host, r.Status, r.Err, r.Log)
+			continue
+		}
+		fmt.Printf("[%s] %s\n", r.Host, r.Status)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d hosts failed", failed, len(results))
+	}
+	return nil
+}
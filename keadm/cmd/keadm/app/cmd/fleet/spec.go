@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fleet
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Host describes one edge node to provision, in the spirit of a KubeKey hosts
+// entry: SSH connection info plus the same per-node settings `keadm join`
+// would otherwise take as flags.
+type Host struct {
+	Name                  string            `json:"name"`
+	Address               string            `json:"address"`
+	SSHPort               int               `json:"sshPort,omitempty"`
+	User                  string            `json:"user"`
+	Password              string            `json:"password,omitempty"`
+	PrivateKeyPath        string            `json:"privateKeyPath,omitempty"`
+	EdgeNodeName          string            `json:"edgeNodeName,omitempty"`
+	EdgeNodeIP            string            `json:"edgeNodeIP,omitempty"`
+	Labels                map[string]string `json:"labels,omitempty"`
+	Taints                []string          `json:"taints,omitempty"`
+}
+
+// EdgeClusterSpec is the top-level "EdgeCluster" document `keadm fleet` reads:
+// shared join settings plus the list of hosts to apply them to.
+type EdgeClusterSpec struct {
+	KubeEdgeVersion string   `json:"kubeEdgeVersion"`
+	CloudCoreIPs    []string `json:"cloudCoreIPs"`
+	RuntimeType     string   `json:"runtimeType,omitempty"`
+	Token           string   `json:"token"`
+	Hosts           []Host   `json:"hosts"`
+	// MaxParallel bounds how many hosts are provisioned concurrently; 0 means
+	// the fleet package's own default.
+	MaxParallel int `json:"maxParallel,omitempty"`
+	// Rootless passes --rootless to every host's `keadm join`.
+	Rootless bool `json:"rootless,omitempty"`
+	// PodFilterConfig passes --pod-filter-config to every host's `keadm join`.
+	PodFilterConfig string `json:"podFilterConfig,omitempty"`
+	// PodManifestPath passes --pod-manifest-path to every host's `keadm join`.
+	PodManifestPath string `json:"podManifestPath,omitempty"`
+	// IgnorePreflightErrors passes --ignore-preflight-errors to every host's
+	// `keadm join`.
+	IgnorePreflightErrors []string `json:"ignorePreflightErrors,omitempty"`
+}
+
+// LoadEdgeClusterSpec reads and validates an EdgeCluster YAML document.
+func LoadEdgeClusterSpec(path string) (*EdgeClusterSpec, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read fleet config %s: %v", path, err)
+	}
+	var spec EdgeClusterSpec
+	if err := yaml.Unmarshal(body, &spec); err != nil {
+		return nil, fmt.Errorf("cannot parse fleet config %s: %v", path, err)
+	}
+	if len(spec.Hosts) == 0 {
+		return nil, fmt.Errorf("fleet config %s declares no hosts", path)
+	}
+	for i, h := range spec.Hosts {
+		if h.Name == "" || h.Address == "" {
+			return nil, fmt.Errorf("fleet config %s: host[%d] must set name and address", path, i)
+		}
+	}
+	return &spec, nil
+}
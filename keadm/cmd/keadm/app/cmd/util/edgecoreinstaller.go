@@ -17,28 +17,43 @@ limitations under the License.
 package util
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"golang.org/x/sys/unix"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/kubeedge/kubeedge/common/constants"
 	types "github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/common"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/util/preflight"
 	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
 	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1/validation"
 	"github.com/kubeedge/kubeedge/pkg/util"
 )
 
+// defaultPodFilterConfigMap is used as the ConfigMap namespace when --pod-filter-config
+// is given a bare name instead of a "namespace/name" reference.
+const defaultPodFilterConfigMap = "kubeedge"
+
+// defaultStaticPodPath is where edgecore looks for static-pod manifests when
+// --pod-manifest-path is not set.
+const defaultStaticPodPath = "/etc/kubeedge/manifests"
+
 // KubeEdgeInstTool embeds Common struct and contains cloud node ip:port information
 // It implements ToolsInstaller interface
 type KubeEdgeInstTool struct {
 	Common
 	CertPath              string
-	CloudCoreIP           string
+	CloudCoreIPs          []string
 	EdgeNodeName          string
 	RuntimeType           string
 	RemoteRuntimeEndpoint string
@@ -53,11 +68,17 @@ type KubeEdgeInstTool struct {
 	QuicPort              string
 	TunnelPort            string
 	Labels                []string
+	Rootless              bool
+	PodFilterConfig       string
+	IgnorePreflightErrors []string
+	StaticPodPath         string
 }
 
 // InstallTools downloads KubeEdge for the specified version
 // and makes the required configuration changes and initiates edgecore.
-func (ku *KubeEdgeInstTool) InstallTools() error {
+// ctx is accepted to satisfy ToolsInstaller; edgecore's own install/start steps
+// are not yet cancellable and run to completion once started.
+func (ku *KubeEdgeInstTool) InstallTools(ctx context.Context) error {
 	ku.SetOSInterface(GetOSInterface())
 
 	edgeCoreRunning, err := ku.IsKubeEdgeProcessRunning(KubeEdgeBinaryName)
@@ -68,11 +89,30 @@ func (ku *KubeEdgeInstTool) InstallTools() error {
 		return fmt.Errorf("EdgeCore is already running on this node, please run reset to clean up first")
 	}
 
+	if ku.Rootless {
+		if err := checkRootlessCgroupDelegation(); err != nil {
+			return err
+		}
+	}
+
+	wsServers, httpHostPorts, quicHostPorts, tunnelHostPorts := ku.cloudCoreServers()
+	probeEndpoints := append(append(append(append([]string{}, wsServers...), httpHostPorts...), quicHostPorts...), tunnelHostPorts...)
+	checks := preflight.JoinChecks(preflight.JoinCheckOptions{
+		RemoteRuntimeEndpoint: ku.RemoteRuntimeEndpoint,
+		CGroupDriver:          ku.CGroupDriver,
+		CloudCoreEndpoints:    probeEndpoints,
+		ConfigDir:             KubeEdgeConfigDir,
+	})
+	if err := preflight.RunChecks(checks, sets.NewString(ku.IgnorePreflightErrors...), fmt.Printf); err != nil {
+		return err
+	}
+
 	ku.SetKubeEdgeVersion(ku.ToolVersion)
 
 	opts := &types.InstallOptions{
 		TarballPath:   ku.TarballPath,
 		ComponentType: types.EdgeCore,
+		Rootless:      ku.Rootless,
 	}
 
 	if ku.Region == "en" {
@@ -90,6 +130,10 @@ func (ku *KubeEdgeInstTool) InstallTools() error {
 		return err
 	}
 
+	if ku.Rootless {
+		return ku.runRootlessEdgeCore()
+	}
+
 	err = ku.RunEdgeCore()
 	if err != nil {
 		return err
@@ -97,6 +141,166 @@ func (ku *KubeEdgeInstTool) InstallTools() error {
 	return nil
 }
 
+// checkRootlessCgroupDelegation fails fast when /sys/fs/cgroup is not writable by
+// the current user or the cpu/pids controllers have not been delegated, mirroring
+// the check rootless k3s performs before starting an unprivileged kubelet.
+func checkRootlessCgroupDelegation() error {
+	const cgroupRoot = "/sys/fs/cgroup"
+	if err := unix.Access(cgroupRoot, unix.W_OK); err != nil {
+		return fmt.Errorf("--rootless requires %s to be writable by the current user (delegate it via a user systemd slice): %v", cgroupRoot, err)
+	}
+
+	controllersFile := filepath.Join(cgroupRoot, "cgroup.controllers")
+	body, err := ioutil.ReadFile(controllersFile)
+	if err != nil {
+		return fmt.Errorf("--rootless requires cgroup v2 with delegated controllers, cannot read %s: %v", controllersFile, err)
+	}
+	controllers := strings.Fields(string(body))
+	for _, want := range []string{"cpu", "pids"} {
+		delegated := false
+		for _, c := range controllers {
+			if c == want {
+				delegated = true
+				break
+			}
+		}
+		if !delegated {
+			return fmt.Errorf("--rootless requires the %q cgroup controller to be delegated, only found %v in %s", want, controllers, controllersFile)
+		}
+	}
+	return nil
+}
+
+// xdgRuntimeDir returns $XDG_RUNTIME_DIR, falling back to the well-known
+// /run/user/<uid> path used by rootless container runtimes when it is unset.
+func xdgRuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	return fmt.Sprintf("/run/user/%d", os.Getuid())
+}
+
+// xdgDataHome returns $XDG_DATA_HOME, falling back to $HOME/.local/share.
+func xdgDataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.Getenv("HOME"), ".local", "share")
+}
+
+// xdgConfigHome returns $XDG_CONFIG_HOME, falling back to $HOME/.config. User
+// systemd units must live under here rather than /etc/systemd/system.
+func xdgConfigHome() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config")
+}
+
+// rootlessInstallRoot is where --rootless installs binaries and configs,
+// instead of the default system-wide /usr/local/bin and /etc/kubeedge.
+func rootlessInstallRoot() string {
+	return filepath.Join(xdgDataHome(), "kubeedge")
+}
+
+// rootlessEdgeCoreUnit is the user systemd unit template written to
+// $XDG_CONFIG_HOME/systemd/user/edgecore.service for --rootless installs.
+const rootlessEdgeCoreUnit = `[Unit]
+Description=KubeEdge EdgeCore (rootless)
+
+[Service]
+ExecStart=%s --config=%s
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`
+
+// runRootlessEdgeCore starts edgecore as a user systemd unit (systemctl --user)
+// rather than the system-wide unit RunEdgeCore installs, since an unprivileged
+// user cannot write to /etc/systemd/system. Binaries and configs are addressed
+// under rootlessInstallRoot (XDG_DATA_HOME) rather than the usual system paths.
+func (ku *KubeEdgeInstTool) runRootlessEdgeCore() error {
+	installRoot := rootlessInstallRoot()
+	unitDir := filepath.Join(xdgConfigHome(), "systemd", "user")
+	if err := os.MkdirAll(unitDir, 0750); err != nil {
+		return fmt.Errorf("cannot create %s: %v", unitDir, err)
+	}
+
+	binPath := filepath.Join(installRoot, "bin", KubeEdgeBinaryName)
+	configPath := filepath.Join(installRoot, "config", KubeEdgeEdgeCoreNewYaml)
+	unit := fmt.Sprintf(rootlessEdgeCoreUnit, binPath, configPath)
+	unitPath := filepath.Join(unitDir, "edgecore.service")
+	if err := ioutil.WriteFile(unitPath, []byte(unit), 0640); err != nil {
+		return fmt.Errorf("cannot write %s: %v", unitPath, err)
+	}
+
+	for _, args := range [][]string{
+		{"--user", "daemon-reload"},
+		{"--user", "enable", "--now", "edgecore"},
+	} {
+		cmd := exec.Command("systemctl", args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("systemctl %v failed: %v", args, err)
+		}
+	}
+	return nil
+}
+
+// normalizeCloudCoreIPs flattens comma-separated entries so --cloudcore-ipport may be
+// repeated (StringArrayVar) or comma-separated like the other multi-value flags here.
+func normalizeCloudCoreIPs(ips []string) []string {
+	var out []string
+	for _, ip := range ips {
+		for _, part := range strings.Split(ip, ",") {
+			if part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// cloudCoreServers resolves --cloudcore-ipport plus --cert-port/--quic-port/
+// --tunnel-port into the websocket endpoints (as given) and the host:port
+// pairs edgecore actually dials for the HTTPS, Quic and tunnel servers, so
+// preflight and config generation probe/configure the exact same endpoints.
+func (ku *KubeEdgeInstTool) cloudCoreServers() (wsServers, httpHostPorts, quicHostPorts, tunnelHostPorts []string) {
+	wsServers = normalizeCloudCoreIPs(ku.CloudCoreIPs)
+
+	httpPort := ku.CertPort
+	if httpPort == "" {
+		httpPort = "10002"
+	}
+	quicPort := ku.QuicPort
+	if quicPort == "" {
+		quicPort = "10001"
+	}
+	tunnelPort := ku.TunnelPort
+	if tunnelPort == "" {
+		tunnelPort = strconv.Itoa(constants.DefaultTunnelPort)
+	}
+
+	for _, ipport := range wsServers {
+		host := strings.Split(ipport, ":")[0]
+		httpHostPorts = append(httpHostPorts, net.JoinHostPort(host, httpPort))
+		quicHostPorts = append(quicHostPorts, net.JoinHostPort(host, quicPort))
+		tunnelHostPorts = append(tunnelHostPorts, net.JoinHostPort(host, tunnelPort))
+	}
+	return wsServers, httpHostPorts, quicHostPorts, tunnelHostPorts
+}
+
+// mergeFeatureGate returns gates with name=value set, initializing the map if needed.
+func mergeFeatureGate(gates map[string]bool, name string, value bool) map[string]bool {
+	if gates == nil {
+		gates = map[string]bool{}
+	}
+	gates[name] = value
+	return gates
+}
+
 func (ku *KubeEdgeInstTool) createEdgeConfigFiles() error {
 	//This makes sure the path is created, if it already exists also it is fine
 	err := os.MkdirAll(KubeEdgeConfigDir, os.ModePerm)
@@ -105,7 +309,17 @@ func (ku *KubeEdgeInstTool) createEdgeConfigFiles() error {
 	}
 
 	edgeCoreConfig := v1alpha1.NewDefaultEdgeCoreConfig()
-	edgeCoreConfig.Modules.EdgeHub.WebSocket.Server = ku.CloudCoreIP
+
+	cloudCoreIPs, httpHostPorts, quicHostPorts, tunnelHostPorts := ku.cloudCoreServers()
+
+	var httpServers []string
+	for _, hostPort := range httpHostPorts {
+		httpServers = append(httpServers, "https://"+hostPort)
+	}
+	edgeCoreConfig.Modules.EdgeHub.WebSocket.Server = cloudCoreIPs
+	edgeCoreConfig.Modules.EdgeHub.HTTPServer = httpServers
+	edgeCoreConfig.Modules.EdgeHub.Quic.Server = quicHostPorts
+	edgeCoreConfig.Modules.EdgeStream.TunnelServer = tunnelHostPorts
 
 	if ku.EdgeNodeName != "" {
 		edgeCoreConfig.Modules.Edged.HostnameOverride = ku.EdgeNodeName
@@ -119,24 +333,6 @@ func (ku *KubeEdgeInstTool) createEdgeConfigFiles() error {
 		edgeCoreConfig.Modules.Edged.RuntimeType = ku.RuntimeType
 	}
 
-	if ku.CertPort != "" {
-		edgeCoreConfig.Modules.EdgeHub.HTTPServer = "https://" + strings.Split(ku.CloudCoreIP, ":")[0] + ":" + ku.CertPort
-	} else {
-		edgeCoreConfig.Modules.EdgeHub.HTTPServer = "https://" + strings.Split(ku.CloudCoreIP, ":")[0] + ":10002"
-	}
-
-	if ku.QuicPort != "" {
-		edgeCoreConfig.Modules.EdgeHub.Quic.Server = strings.Split(ku.CloudCoreIP, ":")[0] + ":" + ku.QuicPort
-	} else {
-		edgeCoreConfig.Modules.EdgeHub.Quic.Server = strings.Split(ku.CloudCoreIP, ":")[0] + ":10001"
-	}
-
-	if ku.TunnelPort != "" {
-		edgeCoreConfig.Modules.EdgeStream.TunnelServer = strings.Split(ku.CloudCoreIP, ":")[0] + ":" + ku.TunnelPort
-	} else {
-		edgeCoreConfig.Modules.EdgeStream.TunnelServer = strings.Split(ku.CloudCoreIP, ":")[0] + ":10004"
-	}
-
 	// add NoSchedule taints
 	if ku.HasDefaultTaint {
 		taint := corev1.Taint{
@@ -160,17 +356,22 @@ func (ku *KubeEdgeInstTool) createEdgeConfigFiles() error {
 	if ku.RemoteRuntimeEndpoint != "" {
 		edgeCoreConfig.Modules.Edged.RemoteRuntimeEndpoint = ku.RemoteRuntimeEndpoint
 		edgeCoreConfig.Modules.Edged.RemoteImageEndpoint = ku.RemoteRuntimeEndpoint
+	} else if ku.Rootless {
+		rootlessEndpoint := fmt.Sprintf("unix://%s/containerd-rootless/containerd.sock", xdgRuntimeDir())
+		edgeCoreConfig.Modules.Edged.RemoteRuntimeEndpoint = rootlessEndpoint
+		edgeCoreConfig.Modules.Edged.RemoteImageEndpoint = rootlessEndpoint
+	}
+
+	if ku.Rootless {
+		// an unprivileged kubelet cannot drive the systemd cgroup driver, regardless
+		// of --cgroupdriver
+		edgeCoreConfig.Modules.Edged.CGroupDriver = v1alpha1.CGroupDriverCGroupFS
+		edgeCoreConfig.Modules.Edged.FeatureGates = mergeFeatureGate(edgeCoreConfig.Modules.Edged.FeatureGates, "KubeletInUserNamespace", true)
 	}
+
 	if ku.Token != "" {
 		edgeCoreConfig.Modules.EdgeHub.Token = ku.Token
 	}
-	cloudCoreIP := strings.Split(ku.CloudCoreIP, ":")[0]
-	if ku.CertPort != "" {
-		edgeCoreConfig.Modules.EdgeHub.HTTPServer = "https://" + cloudCoreIP + ":" + ku.CertPort
-	} else {
-		edgeCoreConfig.Modules.EdgeHub.HTTPServer = "https://" + cloudCoreIP + ":10002"
-	}
-	edgeCoreConfig.Modules.EdgeStream.TunnelServer = net.JoinHostPort(cloudCoreIP, strconv.Itoa(constants.DefaultTunnelPort))
 
 	if len(ku.Labels) >= 1 {
 		labelsMap := make(map[string]string)
@@ -182,6 +383,27 @@ func (ku *KubeEdgeInstTool) createEdgeConfigFiles() error {
 		edgeCoreConfig.Modules.Edged.Labels = labelsMap
 	}
 
+	staticPodPath := ku.StaticPodPath
+	if staticPodPath == "" {
+		staticPodPath = defaultStaticPodPath
+	}
+	if err := os.MkdirAll(staticPodPath, 0750); err != nil {
+		return fmt.Errorf("not able to create %s folder path", staticPodPath)
+	}
+	edgeCoreConfig.Modules.Edged.StaticPodPath = staticPodPath
+
+	if ku.PodFilterConfig != "" {
+		namespace, name := defaultPodFilterConfigMap, ku.PodFilterConfig
+		if parts := strings.SplitN(ku.PodFilterConfig, "/", 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		}
+		edgeCoreConfig.Modules.EdgeHub.PodFilter = &v1alpha1.PodFilter{
+			Enable:             true,
+			ConfigMapNamespace: namespace,
+			ConfigMapName:      name,
+		}
+	}
+
 	if errs := validation.ValidateEdgeCoreConfiguration(edgeCoreConfig); len(errs) > 0 {
 		return errors.New(util.SpliceErrors(errs.ToAggregate().Errors()))
 	}
@@ -193,6 +415,13 @@ func (ku *KubeEdgeInstTool) TearDown() error {
 	ku.SetOSInterface(GetOSInterface())
 	ku.SetKubeEdgeVersion(ku.ToolVersion)
 
+	if ku.Rootless {
+		cmd := exec.Command("systemctl", "--user", "disable", "--now", "edgecore")
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+
 	//Kill edge core process
 	if err := ku.KillKubeEdgeBinary(KubeEdgeBinaryName); err != nil {
 		return err
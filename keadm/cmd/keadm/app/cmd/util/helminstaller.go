@@ -2,18 +2,27 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
-	"sort"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/blang/semver"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/storage/driver"
-	"helm.sh/helm/v3/pkg/strvals"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"sigs.k8s.io/yaml"
 
 	keCharts "github.com/kubeedge/kubeedge/build/helm/charts"
 	"github.com/kubeedge/kubeedge/common/constants"
@@ -31,6 +40,12 @@ const (
 	DefaultHelmInstall     = true
 	DefaultHelmWait        = true
 	DefaultHelmCreateNs    = true
+	// DefaultTemplateKubeVersion is used to set Capabilities.KubeVersion when --template
+	// is given without an explicit --kube-version.
+	DefaultTemplateKubeVersion = "v1.24.0"
+	// DefaultChartCacheDir is where charts resolved from --chart-repo/--chart-ref are cached.
+	DefaultChartCacheDir = ".keadm/cache/charts"
+	OCIRepoPrefix        = "oci://"
 )
 
 // KubeCloudHelmInstTool embeds Common struct
@@ -49,11 +64,33 @@ type KubeCloudHelmInstTool struct {
 	Profile          string
 	ProfileKey       string
 	Force            bool
+	Template         bool
+	KubeVersion      string
+	APIVersions      []string
+	ChartRepo        string
+	ChartVersion     string
+	ChartRef         string
+	Username         string
+	Password         string
+	CAFile           string
+	Verify           bool
+	Keyring          string
+	Atomic           bool
+	CleanupOnFail    bool
+	ReuseValues      bool
+	ResetValues      bool
+	ProfileDir       string
+	ValuesFiles      []string
+	SetStrings       []string
+	SetFiles         []string
+	SetJSON          []string
 }
 
 // InstallTools downloads KubeEdge for the specified version
 // and makes the required configuration changes and initiates cloudcore.
-func (cu *KubeCloudHelmInstTool) InstallTools() error {
+// The given ctx is propagated down to the Helm install/upgrade call so a
+// Ctrl-C can abort a hanging release instead of leaving it half-applied.
+func (cu *KubeCloudHelmInstTool) InstallTools(ctx context.Context) error {
 	cu.SetOSInterface(GetOSInterface())
 	cu.SetKubeEdgeVersion(cu.ToolVersion)
 
@@ -68,12 +105,15 @@ func (cu *KubeCloudHelmInstTool) InstallTools() error {
 		}
 	}
 
-	err := cu.IsK8SComponentInstalled(cu.KubeConfig, cu.Master)
-	if err != nil {
-		return err
-	}
+	// --template renders the chart locally and never talks to a live cluster
+	if !cu.Template {
+		err := cu.IsK8SComponentInstalled(cu.KubeConfig, cu.Master)
+		if err != nil {
+			return err
+		}
 
-	fmt.Println("Kubernetes version verification passed, KubeEdge installation will start...")
+		fmt.Println("Kubernetes version verification passed, KubeEdge installation will start...")
+	}
 
 	// prepare to render
 	if err := cu.BeforeRenderer(); err != nil {
@@ -86,12 +126,14 @@ func (cu *KubeCloudHelmInstTool) InstallTools() error {
 		return fmt.Errorf("cannot build chart render %s, error: %s", renderer.componentName, err.Error())
 	}
 
-	// load the charts to this renderer
-	if err := renderer.LoadChart(); err != nil {
-		return fmt.Errorf("cannot load the given charts %s, error: %s", renderer.componentName, err.Error())
+	// load the charts to this renderer, unless an external chart was already resolved
+	if renderer.chart == nil {
+		if err := renderer.LoadChart(); err != nil {
+			return fmt.Errorf("cannot load the given charts %s, error: %s", renderer.componentName, err.Error())
+		}
 	}
 
-	if err := cu.RunHelmInstall(renderer); err != nil {
+	if err := cu.RunHelmInstall(ctx, renderer); err != nil {
 		return err
 	}
 
@@ -99,8 +141,58 @@ func (cu *KubeCloudHelmInstTool) InstallTools() error {
 	return nil
 }
 
+// Upgrade renders the chart for the requested profile and upgrades the already-deployed release,
+// backing `keadm upgrade`.
+func (cu *KubeCloudHelmInstTool) Upgrade(ctx context.Context) error {
+	cu.SetOSInterface(GetOSInterface())
+	cu.SetKubeEdgeVersion(cu.ToolVersion)
+
+	if err := cu.BeforeRenderer(); err != nil {
+		return err
+	}
+
+	renderer, err := cu.buildRenderer()
+	if err != nil {
+		return fmt.Errorf("cannot build chart renderer: %s", err.Error())
+	}
+	if renderer.chart == nil {
+		if err := renderer.LoadChart(); err != nil {
+			return fmt.Errorf("cannot load the given charts %s, error: %s", renderer.componentName, err.Error())
+		}
+	}
+
+	if err := cu.RunHelmUpgrade(ctx, renderer); err != nil {
+		return err
+	}
+
+	fmt.Println("CloudCore upgraded")
+	return nil
+}
+
+// Rollback backs `keadm rollback [REVISION]`.
+func (cu *KubeCloudHelmInstTool) Rollback(revision int) error {
+	return cu.RunHelmRollback(CloudCoreHelmComponent, revision)
+}
+
+// History backs `keadm history` and prints every recorded revision of the cloudcore release.
+func (cu *KubeCloudHelmInstTool) History() error {
+	revisions, err := cu.RunHelmHistory(CloudCoreHelmComponent)
+	if err != nil {
+		return err
+	}
+	for _, rev := range revisions {
+		fmt.Printf("REVISION: %d\tSTATUS: %s\tCHART: %s\tDESCRIPTION: %s\n",
+			rev.Version, rev.Info.Status, rev.Chart.Metadata.Name+"-"+rev.Chart.Metadata.Version, rev.Info.Description)
+	}
+	return nil
+}
+
 // BeforeRenderer handles the value of the profile.
 func (cu *KubeCloudHelmInstTool) BeforeRenderer() error {
+	if err := DiscoverExternalProfiles(cu.ProfileDir); err != nil {
+		return err
+	}
+
 	if cu.Profile == "" {
 		cu.Profile = fmt.Sprintf("%s=%s", types.VersionProfileKey, types.HelmSupportedMinVersion)
 	}
@@ -133,25 +225,48 @@ func (cu *KubeCloudHelmInstTool) buildRenderer() (*Renderer, error) {
 	if err != nil {
 		return nil, err
 	}
-	// confirm which chart to load
+
+	// an explicit --chart-repo/--chart-ref pins the chart to an out-of-band source,
+	// bypassing the charts embedded in the keadm binary entirely.
+	if cu.ChartRepo != "" || cu.ChartRef != "" {
+		chrt, err := cu.resolveExternalChart()
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve chart %s from %s, error: %s", cu.ChartRef, cu.ChartRepo, err.Error())
+		}
+		componentName := cu.ChartRef
+		if componentName == "" {
+			componentName = cu.ProfileKey
+		}
+		render := NewGenericRenderer(keCharts.BuiltinOrDir(DefaultHelmRoot), "", componentName, cu.Namespace, profileValsMap)
+		render.chart = chrt
+		return render, nil
+	}
+
+	// confirm which chart to load. Registered AddonProfile implementations (built-in
+	// or discovered under ~/.keadm/profiles) own their own chart location; anything
+	// else is treated as an addon chart shipped under AddonsHelmDir/<profileKey>.
 	var componentName string
 	var subDir string
-	if cu.isInnerProfile() {
-		switch cu.ProfileKey {
-		case types.VersionProfileKey, types.IptablesMgrProfileKey:
-			componentName = CloudCoreHelmComponent
-			subDir = CloudCoreHelmDir
-		case types.EdgemeshProfileKey:
-			// edgemesh will integrate later
-			componentName = EdgemeshHelmComponent
-			subDir = EdgemeshHelmDir
-		default:
-			componentName = CloudCoreHelmComponent
-			subDir = CloudCoreHelmDir
+	switch {
+	case cu.ProfileKey == "" || cu.ProfileKey == DefaultProfileString:
+		componentName = CloudCoreHelmComponent
+		subDir = CloudCoreHelmDir
+	default:
+		if profile, ok := LookupAddonProfile(cu.ProfileKey); ok {
+			subDir = profile.ChartLocation()
+			// a profile whose chart is the CloudCore chart itself (eg "version",
+			// "iptablesMgrMode") modifies the existing cloudcore release; anything
+			// pointing at a different chart (eg "edgemesh") gets its own release,
+			// so it doesn't clobber the cloudcore release.
+			if subDir == CloudCoreHelmDir {
+				componentName = CloudCoreHelmComponent
+			} else {
+				componentName = profile.Name()
+			}
+		} else {
+			componentName = cu.ProfileKey
+			subDir = fmt.Sprintf("%s/%s", AddonsHelmDir, cu.ProfileKey)
 		}
-	} else {
-		componentName = cu.ProfileKey
-		subDir = fmt.Sprintf("%s/%s", AddonsHelmDir, cu.ProfileKey)
 	}
 
 	// render the chart with the given values
@@ -159,6 +274,60 @@ func (cu *KubeCloudHelmInstTool) buildRenderer() (*Renderer, error) {
 	return render, nil
 }
 
+// resolveExternalChart downloads the chart pinned by --chart-repo/--chart-ref/--chart-version
+// into a local cache dir and loads it, supporting both classic index.yaml repos and OCI registries.
+func (cu *KubeCloudHelmInstTool) resolveExternalChart() (*chart.Chart, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve home directory to cache charts: %s", err.Error())
+	}
+	cacheDir := filepath.Join(home, DefaultChartCacheDir)
+	if err := os.MkdirAll(cacheDir, 0750); err != nil {
+		return nil, fmt.Errorf("cannot create chart cache dir %s, error: %s", cacheDir, err.Error())
+	}
+
+	settings := cli.New()
+	dl := downloader.ChartDownloader{
+		Out:              os.Stdout,
+		Getters:          getter.All(settings),
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+		Options: []getter.Option{
+			getter.WithBasicAuth(cu.Username, cu.Password),
+			getter.WithTLSClientConfig("", "", cu.CAFile),
+		},
+	}
+
+	chartRef := cu.ChartRef
+	if strings.HasPrefix(cu.ChartRepo, OCIRepoPrefix) {
+		regClient, err := registry.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("cannot create OCI registry client, error: %s", err.Error())
+		}
+		dl.RegistryClient = regClient
+		dl.Options = append(dl.Options, getter.WithRegistryClient(regClient))
+		chartRef = strings.TrimSuffix(cu.ChartRepo, "/") + "/" + cu.ChartRef
+	} else if cu.ChartRepo != "" {
+		dl.Options = append(dl.Options, getter.WithURL(cu.ChartRepo))
+	}
+
+	archivePath, _, err := dl.DownloadTo(chartRef, cu.ChartVersion, cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot download chart %s, error: %s", chartRef, err.Error())
+	}
+
+	if cu.Verify {
+		if cu.Keyring == "" {
+			return nil, fmt.Errorf("--verify requires --keyring to point at the keyring used to sign %s", chartRef)
+		}
+		if _, err := downloader.VerifyChart(archivePath, cu.Keyring); err != nil {
+			return nil, fmt.Errorf("cannot verify chart provenance for %s, error: %s", archivePath, err.Error())
+		}
+	}
+
+	return loader.Load(archivePath)
+}
+
 // RunHelmInstall starts cloudcore deployment with the given flags
 func (cu *KubeCloudHelmInstTool) HelmRenderer(r *Renderer) error {
 	manifiests, err := r.RenderManifest()
@@ -183,8 +352,41 @@ func (cu *KubeCloudHelmInstTool) HelmRenderer(r *Renderer) error {
 	return nil
 }
 
-// RunHelmInstall starts cloudcore deployment with the given flags
-func (cu *KubeCloudHelmInstTool) RunHelmInstall(r *Renderer) error {
+// RunHelmTemplate renders the given chart offline, the same way `helm template` does,
+// so it can run without a live cluster or a valid kubeconfig.
+func (cu *KubeCloudHelmInstTool) RunHelmTemplate(r *Renderer) error {
+	cfg := &action.Configuration{}
+
+	helmInstall := action.NewInstall(cfg)
+	helmInstall.ClientOnly = true
+	helmInstall.DryRun = true
+	helmInstall.Namespace = cu.Namespace
+	helmInstall.ReleaseName = r.componentName
+
+	kubeVersion := cu.KubeVersion
+	if kubeVersion == "" {
+		kubeVersion = DefaultTemplateKubeVersion
+	}
+	parsedVersion, err := chartutil.ParseKubeVersion(kubeVersion)
+	if err != nil {
+		return fmt.Errorf("invalid --kube-version %s, error: %s", kubeVersion, err.Error())
+	}
+	helmInstall.KubeVersion = parsedVersion
+	if len(cu.APIVersions) > 0 {
+		helmInstall.APIVersions = chartutil.VersionSet(cu.APIVersions)
+	}
+
+	rel, err := helmInstall.Run(r.chart, r.profileValsMap)
+	if err != nil {
+		return fmt.Errorf("cannot render the given component %s, error: %s", r.componentName, err.Error())
+	}
+
+	fmt.Println(rel.Manifest)
+	return nil
+}
+
+// newActionConfig builds the Helm action.Configuration shared by install/upgrade/rollback/history.
+func (cu *KubeCloudHelmInstTool) newActionConfig() (*action.Configuration, error) {
 	cf := genericclioptions.NewConfigFlags(true)
 	cf.KubeConfig = &cu.KubeConfig
 	cf.Namespace = &cu.Namespace
@@ -194,6 +396,19 @@ func (cu *KubeCloudHelmInstTool) RunHelmInstall(r *Renderer) error {
 		fmt.Println(fmt.Sprintf(format, v...))
 	}
 	if err := cfg.Init(cf, cu.Namespace, "", logFunc); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// RunHelmInstall starts cloudcore deployment with the given flags
+func (cu *KubeCloudHelmInstTool) RunHelmInstall(ctx context.Context, r *Renderer) error {
+	if cu.Template {
+		return cu.RunHelmTemplate(r)
+	}
+
+	cfg, err := cu.newActionConfig()
+	if err != nil {
 		return err
 	}
 
@@ -207,8 +422,9 @@ func (cu *KubeCloudHelmInstTool) RunHelmInstall(r *Renderer) error {
 		helmUpgrade.Timeout = DefaultHelmTimeout
 	}
 	helmUpgrade.Install = DefaultHelmInstall
+	helmUpgrade.Atomic = cu.Atomic
 
-	_, err := helmUpgrade.Run(r.componentName, r.chart, r.profileValsMap)
+	_, err = helmUpgrade.RunWithContext(ctx, r.componentName, r.chart, r.profileValsMap)
 	if err != nil {
 		// if the error returns is errReleaseNotFound, would try to install it.
 		errReleaseNotFound := driver.NewErrNoDeployedReleases(r.componentName).Error()
@@ -222,17 +438,108 @@ func (cu *KubeCloudHelmInstTool) RunHelmInstall(r *Renderer) error {
 			}
 			helmInstall.CreateNamespace = DefaultHelmCreateNs
 			helmInstall.ReleaseName = r.componentName
+			helmInstall.Atomic = cu.Atomic
 
-			if _, err := helmInstall.Run(r.chart, r.profileValsMap); err != nil {
+			if _, err := helmInstall.RunWithContext(ctx, r.chart, r.profileValsMap); err != nil {
+				if cu.Atomic && ctx.Err() != nil {
+					cu.rollbackCancelledRelease(r.componentName)
+				}
 				return err
 			}
 			return nil
 		}
+		if cu.Atomic && ctx.Err() != nil {
+			cu.rollbackCancelledRelease(r.componentName)
+		}
+		return err
+	}
+	return nil
+}
+
+// rollbackCancelledRelease is best-effort cleanup for a release left half-applied by a
+// cancelled --atomic install/upgrade; helmUpgrade/helmInstall already roll back on failure
+// themselves when Atomic is set, this only covers the ctx-cancellation path.
+func (cu *KubeCloudHelmInstTool) rollbackCancelledRelease(componentName string) {
+	if err := cu.RunHelmRollback(componentName, 0); err != nil {
+		fmt.Printf("Release %s has been cancelled, but automatic rollback failed: %s\n", componentName, err.Error())
+		return
+	}
+	fmt.Printf("Release %s has been cancelled\n", componentName)
+}
+
+// ensureReleaseDeployed refuses to upgrade a release that is not currently in the
+// deployed state, mirroring the safety check used by other Helm-based installers.
+func ensureReleaseDeployed(cfg *action.Configuration, componentName string) error {
+	rel, err := action.NewGet(cfg).Run(componentName)
+	if err != nil {
+		return fmt.Errorf("cannot find release %s, error: %s", componentName, err.Error())
+	}
+	if rel.Info.Status != release.StatusDeployed {
+		return fmt.Errorf("refusing to upgrade %s: current release status is %q, expected %q", componentName, rel.Info.Status, release.StatusDeployed)
+	}
+	return nil
+}
+
+// RunHelmUpgrade upgrades an already-deployed release with the given renderer's chart and values.
+func (cu *KubeCloudHelmInstTool) RunHelmUpgrade(ctx context.Context, r *Renderer) error {
+	cfg, err := cu.newActionConfig()
+	if err != nil {
 		return err
 	}
+
+	if err := ensureReleaseDeployed(cfg, r.componentName); err != nil {
+		return err
+	}
+
+	helmUpgrade := action.NewUpgrade(cfg)
+	helmUpgrade.Namespace = cu.Namespace
+	helmUpgrade.Atomic = cu.Atomic
+	helmUpgrade.CleanupOnFail = cu.CleanupOnFail
+	helmUpgrade.ReuseValues = cu.ReuseValues
+	helmUpgrade.ResetValues = cu.ResetValues
+	helmUpgrade.Wait = DefaultHelmWait
+	helmUpgrade.Timeout = DefaultHelmTimeout
+
+	if _, err := helmUpgrade.RunWithContext(ctx, r.componentName, r.chart, r.profileValsMap); err != nil {
+		return fmt.Errorf("cannot upgrade release %s, error: %s", r.componentName, err.Error())
+	}
 	return nil
 }
 
+// RunHelmRollback rolls componentName back to revision, or to the previous revision if revision is 0.
+func (cu *KubeCloudHelmInstTool) RunHelmRollback(componentName string, revision int) error {
+	cfg, err := cu.newActionConfig()
+	if err != nil {
+		return err
+	}
+
+	helmRollback := action.NewRollback(cfg)
+	helmRollback.Version = revision
+	helmRollback.Wait = DefaultHelmWait
+	helmRollback.Timeout = DefaultHelmTimeout
+	helmRollback.CleanupOnFail = cu.CleanupOnFail
+
+	if err := helmRollback.Run(componentName); err != nil {
+		return fmt.Errorf("cannot roll back release %s, error: %s", componentName, err.Error())
+	}
+	return nil
+}
+
+// RunHelmHistory lists every recorded revision of componentName, oldest first.
+func (cu *KubeCloudHelmInstTool) RunHelmHistory(componentName string) ([]*release.Release, error) {
+	cfg, err := cu.newActionConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := action.NewHistory(cfg).Run(componentName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get history for release %s, error: %s", componentName, err.Error())
+	}
+	releaseutil.SortByRevision(history)
+	return history, nil
+}
+
 // TearDown method will remove the edge node from api-server and stop cloudcore process
 func (cu *KubeCloudHelmInstTool) TearDown() error {
 	// clean kubeedge namespace
@@ -244,6 +551,16 @@ func (cu *KubeCloudHelmInstTool) TearDown() error {
 }
 
 func (cu *KubeCloudHelmInstTool) checkProfile() error {
+	if cu.ProfileKey == "" || cu.ProfileKey == DefaultProfileString {
+		return nil
+	}
+	if _, ok := LookupAddonProfile(cu.ProfileKey); ok {
+		return nil
+	}
+
+	// not a registered AddonProfile: fall back to the legacy embedded-chart profile
+	// listing, which still covers addon charts that haven't been migrated yet
+	// (mapper-generator, sedna, etc.)
 	validProfiles, err := readProfiles(DefaultHelmRoot)
 	if err != nil {
 		return fmt.Errorf("cannot list profile")
@@ -256,40 +573,23 @@ func (cu *KubeCloudHelmInstTool) checkProfile() error {
 }
 
 func (cu *KubeCloudHelmInstTool) handleProfile(profileValue string) error {
-	switch cu.ProfileKey {
-	case types.VersionProfileKey:
-		profileValueSuffix := strings.TrimPrefix(profileValue, "v")
-		// confirm it startswith "v"
-		if profileValue != profileValueSuffix {
-			version, err := semver.Make(profileValueSuffix)
-			if err != nil {
-				return err
-			}
-			minVersion, _ := semver.Make(strings.TrimPrefix(types.HelmSupportedMinVersion, "v"))
-			if version.LT(minVersion) {
-				return fmt.Errorf("the given version %s is not supported, you can try binary deployments with this version", profileValue)
-			}
-
-			cu.Sets = append(cu.Sets, fmt.Sprintf("%s=v%s", "cloudCore.image.tag", profileValueSuffix))
-			cu.Sets = append(cu.Sets, fmt.Sprintf("%s=v%s", "iptablesManager.image.tag", profileValueSuffix))
-		} else {
-			cu.Sets = append(cu.Sets, fmt.Sprintf("%s=%s", "cloudCore.image.tag", profileValue))
-			cu.Sets = append(cu.Sets, fmt.Sprintf("%s=%s", "iptablesManager.image.tag", profileValue))
-		}
-	case types.IptablesMgrProfileKey:
-		switch profileValue {
-		case types.InternalIptablesMgrMode, types.ExternalIptablesMgrMode:
-			cu.Sets = append(cu.Sets, fmt.Sprintf("%s=%s", "iptablesManager.mode", profileValue))
-		default:
-			profileValue = types.ExternalIptablesMgrMode
-		}
-	default:
+	profile, ok := LookupAddonProfile(cu.ProfileKey)
+	if !ok {
+		// unregistered addon charts carry no special value translation
+		return nil
 	}
+	if err := profile.ValidateValue(profileValue); err != nil {
+		return err
+	}
+	cu.Sets = append(cu.Sets, profile.TranslateToSets(profileValue)...)
 	return nil
 }
 
+// rebuildFlagVals translates the image/address convenience flags into --set entries.
+// Precedence among repeated or overlapping keys (including list indices such as
+// cloudCore.modules.cloudHub.advertiseAddress[0]) is left entirely to the
+// MergeValues pipeline in combineProfVals, which applies --set entries in order.
 func (cu *KubeCloudHelmInstTool) rebuildFlagVals() error {
-	// combine the flag values
 	if cu.AdvertiseAddress != "" {
 		for index, addr := range strings.Split(cu.AdvertiseAddress, ",") {
 			cu.Sets = append(cu.Sets, fmt.Sprintf("%s[%d]=%s", "cloudCore.modules.cloudHub.advertiseAddress", index, addr))
@@ -307,55 +607,58 @@ func (cu *KubeCloudHelmInstTool) rebuildFlagVals() error {
 	if cu.IptablesMgrTag != "" {
 		cu.Sets = append(cu.Sets, fmt.Sprintf("%s=%s", "iptablesManager.image.tag", cu.IptablesMgrTag))
 	}
-
-	var formerValue string
-	sets := make([]string, 0)
-
-	sort.Strings(cu.Sets)
-	for index, s := range cu.Sets {
-		p := strings.Split(s, "=")
-
-		if len(p) < 2 {
-			fmt.Println("Unsported flags:", s)
-			continue
-		}
-
-		if index > 0 && p[0] == formerValue {
-			// duplicate removal
-			sets[len(sets)-1] = s
-		} else {
-			sets = append(sets, s)
-		}
-
-		formerValue = p[0]
-	}
-
-	cu.Sets = sets
 	return nil
 }
 
-func (cu *KubeCloudHelmInstTool) isInnerProfile() bool {
-	return cu.ProfileKey == "" || cu.ProfileKey == DefaultProfileString || cu.ProfileKey == types.IptablesMgrProfileKey || cu.ProfileKey == types.EdgemeshProfileKey
-}
-
-// combineProfVals combines the values of the given manifests and flags into a map.
+// combineProfVals merges the chart defaults, the profile's own values.yaml, any
+// --values files (in order) and --set/--set-string/--set-file/--set-json (in
+// order) using the standard Helm value-merging pipeline, so precedence matches
+// `helm install`/`helm upgrade` exactly.
 func (cu *KubeCloudHelmInstTool) combineProfVals() (map[string]interface{}, error) {
-	profileValsMap := map[string]interface{}{}
-
-	profileValue, err := LoadValues(cu.ProfileKey, DefaultHelmRoot)
+	profileValue, err := cu.loadProfileDefaultValues()
 	if err != nil {
 		return nil, fmt.Errorf("cannot load profile yaml:%s", err.Error())
 	}
 
-	if err := yaml.Unmarshal([]byte(profileValue), &profileValsMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal values: %v", err)
+	// stage the profile's values.yaml as a real file so it takes part in the same
+	// ValueFiles precedence chain as user-supplied --values files
+	profileFile, err := ioutil.TempFile("", "keadm-profile-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("cannot stage profile values: %s", err.Error())
 	}
-	// User specified a value via --set
-	for _, value := range cu.Sets {
-		if err := strvals.ParseInto(value, profileValsMap); err != nil {
-			return nil, fmt.Errorf("failed parsing --set data:%s", err.Error())
-		}
+	defer os.Remove(profileFile.Name())
+	if _, err := profileFile.WriteString(profileValue); err != nil {
+		profileFile.Close()
+		return nil, fmt.Errorf("cannot stage profile values: %s", err.Error())
+	}
+	if err := profileFile.Close(); err != nil {
+		return nil, fmt.Errorf("cannot stage profile values: %s", err.Error())
+	}
+
+	valOpts := values.Options{
+		ValueFiles:   append([]string{profileFile.Name()}, cu.ValuesFiles...),
+		Values:       cu.Sets,
+		StringValues: cu.SetStrings,
+		FileValues:   cu.SetFiles,
+		JSONValues:   cu.SetJSON,
+	}
+
+	profileValsMap, err := valOpts.MergeValues(getter.All(cli.New()))
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing --values/--set data: %s", err.Error())
 	}
 
 	return profileValsMap, nil
 }
+
+// loadProfileDefaultValues returns the values.yaml content staged ahead of
+// --values/--set. A registered AddonProfile supplies its own via
+// DefaultValues(); anything else falls back to the legacy embedded-chart
+// profile lookup, which still covers addon charts that haven't been
+// migrated to the AddonProfile registry yet.
+func (cu *KubeCloudHelmInstTool) loadProfileDefaultValues() (string, error) {
+	if profile, ok := LookupAddonProfile(cu.ProfileKey); ok {
+		return profile.DefaultValues(), nil
+	}
+	return LoadValues(cu.ProfileKey, DefaultHelmRoot)
+}
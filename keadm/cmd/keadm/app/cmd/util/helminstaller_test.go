@@ -0,0 +1,121 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTempValuesFile stages body as a temporary --values file and returns its path.
+func writeTempValuesFile(t *testing.T, body string) string {
+	t.Helper()
+	f, err := ioutil.TempFile("", "keadm-values-*.yaml")
+	if err != nil {
+		t.Fatalf("cannot create temp values file: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(body); err != nil {
+		t.Fatalf("cannot write temp values file: %v", err)
+	}
+	return f.Name()
+}
+
+// fakeAddonProfile is a test-only AddonProfile, registered under a name that
+// won't collide with the built-in profiles, so combineProfVals's profile
+// lookup can be exercised without depending on them.
+type fakeAddonProfile struct {
+	name          string
+	defaultValues string
+}
+
+func (p *fakeAddonProfile) Name() string              { return p.name }
+func (p *fakeAddonProfile) ChartLocation() string     { return "fake" }
+func (p *fakeAddonProfile) DefaultValues() string     { return p.defaultValues }
+func (p *fakeAddonProfile) ValidateValue(string) error { return nil }
+func (p *fakeAddonProfile) TranslateToSets(string) []string { return nil }
+
+// TestCombineProfValsPrecedence exercises (*KubeCloudHelmInstTool).combineProfVals
+// end to end, confirming the precedence keadm relies on: the profile's own
+// DefaultValues() is the base, a later --values file overrides it, and --set
+// overrides both.
+func TestCombineProfValsPrecedence(t *testing.T) {
+	RegisterAddonProfile(&fakeAddonProfile{
+		name:          "fake-precedence",
+		defaultValues: "cloudCore:\n  image:\n    tag: v0.0.0\n  modules:\n    cloudHub:\n      advertiseAddress:\n      - 1.2.3.4\n",
+	})
+
+	valuesFile := writeTempValuesFile(t, "cloudCore:\n  image:\n    tag: v0.0.1\n  modules:\n    cloudHub:\n      advertiseAddress:\n      - 5.6.7.8\n")
+	defer os.Remove(valuesFile)
+
+	cu := &KubeCloudHelmInstTool{
+		ProfileKey:  "fake-precedence",
+		ValuesFiles: []string{valuesFile},
+		Sets:        []string{"cloudCore.image.tag=v1.12.0"},
+	}
+
+	merged, err := cu.combineProfVals()
+	if err != nil {
+		t.Fatalf("combineProfVals returned error: %v", err)
+	}
+
+	cloudCore := merged["cloudCore"].(map[string]interface{})
+	if got := cloudCore["image"].(map[string]interface{})["tag"]; got != "v1.12.0" {
+		t.Errorf("cloudCore.image.tag = %v, want v1.12.0 (--set must win over the profile default and the --values file)", got)
+	}
+
+	addresses := cloudCore["modules"].(map[string]interface{})["cloudHub"].(map[string]interface{})["advertiseAddress"].([]interface{})
+	if len(addresses) != 1 || addresses[0] != "5.6.7.8" {
+		t.Errorf("advertiseAddress = %v, want [5.6.7.8] (the --values file must win over the profile default)", addresses)
+	}
+}
+
+// TestCombineProfValsRepeatedValuesFiles confirms the later file in a
+// repeated --values list always wins, matching helm's own documented precedence.
+func TestCombineProfValsRepeatedValuesFiles(t *testing.T) {
+	RegisterAddonProfile(&fakeAddonProfile{name: "fake-repeated-values"})
+
+	first := writeTempValuesFile(t, "profile: first\n")
+	defer os.Remove(first)
+	second := writeTempValuesFile(t, "profile: second\n")
+	defer os.Remove(second)
+	third := writeTempValuesFile(t, "profile: third\n")
+	defer os.Remove(third)
+
+	cu := &KubeCloudHelmInstTool{
+		ProfileKey:  "fake-repeated-values",
+		ValuesFiles: []string{first, second, third},
+	}
+
+	merged, err := cu.combineProfVals()
+	if err != nil {
+		t.Fatalf("combineProfVals returned error: %v", err)
+	}
+	if got := merged["profile"]; got != "third" {
+		t.Errorf("profile = %v, want third (last --values file wins)", got)
+	}
+}
+
+// TestCombineProfValsCleansUpStagedProfileFile confirms combineProfVals doesn't
+// leak the temp file it stages for the profile's own values.yaml.
+func TestCombineProfValsCleansUpStagedProfileFile(t *testing.T) {
+	RegisterAddonProfile(&fakeAddonProfile{name: "fake-cleanup", defaultValues: "a: b\n"})
+
+	before, err := filepath.Glob(filepath.Join(os.TempDir(), "keadm-profile-*.yaml"))
+	if err != nil {
+		t.Fatalf("cannot glob temp dir: %v", err)
+	}
+
+	cu := &KubeCloudHelmInstTool{ProfileKey: "fake-cleanup"}
+	if _, err := cu.combineProfVals(); err != nil {
+		t.Fatalf("combineProfVals returned error: %v", err)
+	}
+
+	after, err := filepath.Glob(filepath.Join(os.TempDir(), "keadm-profile-*.yaml"))
+	if err != nil {
+		t.Fatalf("cannot glob temp dir: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("combineProfVals left a staged profile file behind: before=%v after=%v", before, after)
+	}
+}
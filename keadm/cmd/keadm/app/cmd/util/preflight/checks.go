@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preflight runs a set of checks before `keadm join` installs edgecore,
+// modeled on kubeadm's preflight checks: each Check can report non-fatal
+// warnings and fatal errors, and the caller decides which errors to tolerate
+// via --ignore-preflight-errors.
+package preflight
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Check is a single preflight check. Name identifies it for
+// --ignore-preflight-errors=<name> matching.
+type Check interface {
+	Name() string
+	Check() (warnings, errors []error)
+}
+
+// Error wraps the errors raised by a single Check, implementing error so a
+// RunChecks failure can still be inspected check-by-check.
+type Error struct {
+	Check  string
+	Errors []error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[preflight] %s check failed: %v", e.Check, e.Errors)
+}
+
+// RunChecks executes every check, printing warnings as it goes. A check whose
+// name (or "all") appears in ignoredChecks downgrades its errors to warnings.
+// The first error from a non-ignored check is returned; all checks still run
+// so the operator sees every problem in one pass.
+func RunChecks(checks []Check, ignoredChecks sets.String, warningf func(string, ...interface{}) (int, error)) error {
+	var firstErr error
+	for _, c := range checks {
+		warnings, errs := c.Check()
+		for _, w := range warnings {
+			warningf("[preflight] WARNING: %s: %v\n", c.Name(), w)
+		}
+		if len(errs) == 0 {
+			continue
+		}
+		if ignoredChecks.Has("all") || ignoredChecks.Has(c.Name()) {
+			for _, e := range errs {
+				warningf("[preflight] WARNING: %s: %v (ignored)\n", c.Name(), e)
+			}
+			continue
+		}
+		if firstErr == nil {
+			firstErr = &Error{Check: c.Name(), Errors: errs}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+// JoinCheckOptions carries the subset of KubeEdgeInstTool fields the preflight
+// checks need, so this package has no dependency on the util package.
+type JoinCheckOptions struct {
+	RemoteRuntimeEndpoint string
+	CGroupDriver          string
+	CloudCoreEndpoints    []string
+	ConfigDir             string
+}
+
+// JoinChecks returns the standard set of checks `keadm join` runs before
+// installing edgecore.
+func JoinChecks(opts JoinCheckOptions) []Check {
+	return []Check{
+		&KernelCheck{},
+		&KernelModulesCheck{Modules: []string{"br_netfilter", "overlay"}},
+		&SwapCheck{},
+		&CGroupCheck{Driver: opts.CGroupDriver},
+		&CRISocketCheck{Endpoint: opts.RemoteRuntimeEndpoint},
+		&CloudCoreReachabilityCheck{Endpoints: opts.CloudCoreEndpoints},
+		&DirAvailableCheck{Path: opts.ConfigDir},
+		&ConflictingProcessCheck{Binaries: []string{"kubelet", "edgecore"}},
+	}
+}
+
+// KernelCheck reads the running kernel's release string: it errors when
+// uname fails and warns when the release is empty. It does not compare the
+// release against a minimum version.
+type KernelCheck struct{}
+
+func (KernelCheck) Name() string { return "Kernel" }
+
+func (KernelCheck) Check() (warnings, errors []error) {
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err != nil {
+		return nil, []error{fmt.Errorf("cannot read kernel version: %v", err)}
+	}
+	release := charsToString(uname.Release[:])
+	if release == "" {
+		return []error{fmt.Errorf("cannot determine kernel release")}, nil
+	}
+	return nil, nil
+}
+
+func charsToString(b []byte) string {
+	n := 0
+	for n < len(b) && b[n] != 0 {
+		n++
+	}
+	return string(b[:n])
+}
+
+// KernelModulesCheck verifies a fixed list of kernel modules are either built
+// in or loadable.
+type KernelModulesCheck struct {
+	Modules []string
+}
+
+func (KernelModulesCheck) Name() string { return "KernelModules" }
+
+func (c KernelModulesCheck) Check() (warnings, errors []error) {
+	for _, m := range c.Modules {
+		if moduleLoaded(m) {
+			continue
+		}
+		if err := exec.Command("modprobe", m).Run(); err != nil {
+			warnings = append(warnings, fmt.Errorf("module %s is not loaded and modprobe failed: %v", m, err))
+		}
+	}
+	return warnings, nil
+}
+
+func moduleLoaded(name string) bool {
+	body, err := ioutil.ReadFile("/proc/modules")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(body), name)
+}
+
+// SwapCheck warns when swap is enabled, matching kubelet's own preference for
+// swap-off nodes.
+type SwapCheck struct{}
+
+func (SwapCheck) Name() string { return "Swap" }
+
+func (SwapCheck) Check() (warnings, errors []error) {
+	body, err := ioutil.ReadFile("/proc/swaps")
+	if err != nil {
+		return nil, nil
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) > 1 {
+		warnings = append(warnings, fmt.Errorf("swap is enabled, it is recommended to disable it"))
+	}
+	return warnings, nil
+}
+
+// CGroupCheck verifies the host's cgroup layout (v1 or v2) is consistent with
+// the requested --cgroupdriver.
+type CGroupCheck struct {
+	Driver string
+}
+
+func (CGroupCheck) Name() string { return "CGroup" }
+
+func (c CGroupCheck) Check() (warnings, errors []error) {
+	isV2 := false
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err == nil {
+		isV2 = true
+	}
+	if isV2 && c.Driver == "cgroupfs" {
+		warnings = append(warnings, fmt.Errorf("host uses cgroup v2, cgroupfs driver is deprecated in favor of systemd"))
+	}
+	return warnings, nil
+}
+
+// CRISocketCheck dials the configured CRI endpoint and issues a gRPC Version
+// call, the same probe kubeadm performs before joining a node.
+type CRISocketCheck struct {
+	Endpoint string
+}
+
+func (CRISocketCheck) Name() string { return "CRISocket" }
+
+func (c CRISocketCheck) Check() (warnings, errors []error) {
+	if c.Endpoint == "" {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, c.Endpoint, grpc.WithInsecure(), grpc.WithContextDialer(dialUnix), grpc.WithBlock())
+	if err != nil {
+		return nil, []error{fmt.Errorf("cannot reach CRI socket %s: %v", c.Endpoint, err)}
+	}
+	defer conn.Close()
+
+	client := runtimeapi.NewRuntimeServiceClient(conn)
+	if _, err := client.Version(ctx, &runtimeapi.VersionRequest{}); err != nil {
+		return nil, []error{fmt.Errorf("CRI socket %s did not answer Version(): %v", c.Endpoint, err)}
+	}
+	return nil, nil
+}
+
+func dialUnix(ctx context.Context, addr string) (net.Conn, error) {
+	addr = strings.TrimPrefix(addr, "unix://")
+	d := net.Dialer{}
+	return d.DialContext(ctx, "unix", addr)
+}
+
+// CloudCoreReachabilityCheck confirms each CloudCore endpoint resolves over
+// DNS and accepts a TCP connection, catching firewall/typo problems before
+// edgecore starts trying (and retrying) to connect.
+type CloudCoreReachabilityCheck struct {
+	Endpoints []string
+}
+
+func (CloudCoreReachabilityCheck) Name() string { return "CloudCoreReachability" }
+
+func (c CloudCoreReachabilityCheck) Check() (warnings, errors []error) {
+	for _, ep := range c.Endpoints {
+		if ep == "" {
+			continue
+		}
+		host, _, err := net.SplitHostPort(ep)
+		if err != nil {
+			host = ep
+		}
+		if _, err := net.LookupHost(host); err != nil {
+			errors = append(errors, fmt.Errorf("cannot resolve CloudCore host %s: %v", host, err))
+			continue
+		}
+		conn, err := net.DialTimeout("tcp", ep, 2*time.Second)
+		if err != nil {
+			warnings = append(warnings, fmt.Errorf("cannot reach %s yet: %v", ep, err))
+			continue
+		}
+		conn.Close()
+	}
+	return warnings, errors
+}
+
+// DirAvailableCheck verifies Path exists (creating it if missing) and is
+// writable, eg /etc/kubeedge.
+type DirAvailableCheck struct {
+	Path string
+}
+
+func (DirAvailableCheck) Name() string { return "DirAvailable" }
+
+func (c DirAvailableCheck) Check() (warnings, errors []error) {
+	if c.Path == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(c.Path, 0750); err != nil {
+		return nil, []error{fmt.Errorf("cannot create %s: %v", c.Path, err)}
+	}
+	probe := filepath.Join(c.Path, ".keadm-preflight-write-test")
+	if err := ioutil.WriteFile(probe, []byte("ok"), 0600); err != nil {
+		return nil, []error{fmt.Errorf("%s is not writable: %v", c.Path, err)}
+	}
+	os.Remove(probe)
+	return nil, nil
+}
+
+// ConflictingProcessCheck errors out if another kubelet/edgecore process is
+// already running on the host.
+type ConflictingProcessCheck struct {
+	Binaries []string
+}
+
+func (ConflictingProcessCheck) Name() string { return "ConflictingProcess" }
+
+func (c ConflictingProcessCheck) Check() (warnings, errors []error) {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, nil
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		comm, err := ioutil.ReadFile(filepath.Join("/proc", entry.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(string(comm))
+		for _, binary := range c.Binaries {
+			if name == binary {
+				errors = append(errors, fmt.Errorf("%s is already running as pid %s", binary, entry.Name()))
+			}
+		}
+	}
+	return warnings, errors
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/blang/semver"
+	"sigs.k8s.io/yaml"
+
+	types "github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/common"
+)
+
+// DefaultProfileDir is searched, relative to $HOME, for user-supplied AddonProfile
+// specs in addition to any directory passed via --profile-dir.
+const DefaultProfileDir = ".keadm/profiles"
+
+// AddonProfile lets a `--profile key=value` flag pick a chart to render and the Helm
+// --set values to translate that key/value pair into, without keadm hard-coding a
+// switch over every known profile.
+type AddonProfile interface {
+	// Name is the profile key, eg "version", "iptablesMgrMode", "edgemesh".
+	Name() string
+	// ChartLocation is the subdirectory, relative to the chart root, holding this profile's chart.
+	ChartLocation() string
+	// DefaultValues is the values.yaml content applied before the profile value and --set overrides.
+	DefaultValues() string
+	// ValidateValue rejects a value this profile cannot translate.
+	ValidateValue(value string) error
+	// TranslateToSets turns a profile value into the --set entries used to render the chart.
+	TranslateToSets(value string) []string
+}
+
+var (
+	profileRegistryMu sync.Mutex
+	profileRegistry   = map[string]AddonProfile{}
+)
+
+func init() {
+	RegisterAddonProfile(&versionProfile{})
+	RegisterAddonProfile(&iptablesMgrProfile{})
+	RegisterAddonProfile(&edgemeshProfile{})
+}
+
+// RegisterAddonProfile adds p to the registry, replacing any profile already
+// registered under the same name.
+func RegisterAddonProfile(p AddonProfile) {
+	profileRegistryMu.Lock()
+	defer profileRegistryMu.Unlock()
+	profileRegistry[p.Name()] = p
+}
+
+// LookupAddonProfile returns the profile registered under key, if any.
+func LookupAddonProfile(key string) (AddonProfile, bool) {
+	profileRegistryMu.Lock()
+	defer profileRegistryMu.Unlock()
+	p, ok := profileRegistry[key]
+	return p, ok
+}
+
+// versionProfile backs the built-in "version" profile key.
+type versionProfile struct{}
+
+func (versionProfile) Name() string          { return types.VersionProfileKey }
+func (versionProfile) ChartLocation() string { return CloudCoreHelmDir }
+func (versionProfile) DefaultValues() string { return "" }
+
+func (versionProfile) ValidateValue(value string) error {
+	suffix := strings.TrimPrefix(value, "v")
+	if value == suffix {
+		// values not prefixed with "v" are passed through untranslated
+		return nil
+	}
+	version, err := semver.Make(suffix)
+	if err != nil {
+		return err
+	}
+	minVersion, _ := semver.Make(strings.TrimPrefix(types.HelmSupportedMinVersion, "v"))
+	if version.LT(minVersion) {
+		return fmt.Errorf("the given version %s is not supported, you can try binary deployments with this version", value)
+	}
+	return nil
+}
+
+func (versionProfile) TranslateToSets(value string) []string {
+	suffix := strings.TrimPrefix(value, "v")
+	if value != suffix {
+		return []string{
+			fmt.Sprintf("cloudCore.image.tag=v%s", suffix),
+			fmt.Sprintf("iptablesManager.image.tag=v%s", suffix),
+		}
+	}
+	return []string{
+		fmt.Sprintf("cloudCore.image.tag=%s", value),
+		fmt.Sprintf("iptablesManager.image.tag=%s", value),
+	}
+}
+
+// iptablesMgrProfile backs the built-in "iptablesMgrMode" profile key.
+type iptablesMgrProfile struct{}
+
+func (iptablesMgrProfile) Name() string          { return types.IptablesMgrProfileKey }
+func (iptablesMgrProfile) ChartLocation() string { return CloudCoreHelmDir }
+func (iptablesMgrProfile) DefaultValues() string { return "" }
+
+func (iptablesMgrProfile) ValidateValue(value string) error {
+	switch value {
+	case types.InternalIptablesMgrMode, types.ExternalIptablesMgrMode:
+		return nil
+	default:
+		return fmt.Errorf("unsupported iptablesMgrMode %s", value)
+	}
+}
+
+func (iptablesMgrProfile) TranslateToSets(value string) []string {
+	switch value {
+	case types.InternalIptablesMgrMode, types.ExternalIptablesMgrMode:
+		return []string{fmt.Sprintf("iptablesManager.mode=%s", value)}
+	default:
+		return []string{fmt.Sprintf("iptablesManager.mode=%s", types.ExternalIptablesMgrMode)}
+	}
+}
+
+// edgemeshProfile backs the built-in "edgemesh" profile key.
+type edgemeshProfile struct{}
+
+func (edgemeshProfile) Name() string              { return types.EdgemeshProfileKey }
+func (edgemeshProfile) ChartLocation() string     { return EdgemeshHelmDir }
+func (edgemeshProfile) DefaultValues() string     { return "" }
+func (edgemeshProfile) ValidateValue(string) error { return nil }
+func (edgemeshProfile) TranslateToSets(string) []string { return nil }
+
+// externalProfileSpec is the on-disk shape of a vendor- or user-supplied profile
+// discovered under ~/.keadm/profiles/*.yaml or --profile-dir.
+type externalProfileSpec struct {
+	Name          string   `json:"name"`
+	Chart         string   `json:"chart"`
+	AllowedValues []string `json:"allowedValues,omitempty"`
+	DefaultValues string   `json:"defaultValues,omitempty"`
+	// SetTemplate entries may reference the profile value via the literal placeholder {{value}}.
+	SetTemplate []string `json:"setTemplate,omitempty"`
+}
+
+type externalProfile struct {
+	spec externalProfileSpec
+}
+
+func (p *externalProfile) Name() string          { return p.spec.Name }
+func (p *externalProfile) ChartLocation() string { return p.spec.Chart }
+func (p *externalProfile) DefaultValues() string { return p.spec.DefaultValues }
+
+func (p *externalProfile) ValidateValue(value string) error {
+	if len(p.spec.AllowedValues) == 0 {
+		return nil
+	}
+	for _, allowed := range p.spec.AllowedValues {
+		if allowed == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported value %s for profile %s, allowed values: %v", value, p.spec.Name, p.spec.AllowedValues)
+}
+
+func (p *externalProfile) TranslateToSets(value string) []string {
+	sets := make([]string, 0, len(p.spec.SetTemplate))
+	for _, tmpl := range p.spec.SetTemplate {
+		sets = append(sets, strings.ReplaceAll(tmpl, "{{value}}", value))
+	}
+	return sets
+}
+
+// DiscoverExternalProfiles registers every *.yaml AddonProfile spec found under
+// $HOME/.keadm/profiles and, if set, profileDir. A missing directory is not an error.
+func DiscoverExternalProfiles(profileDir string) error {
+	dirs := make([]string, 0, 2)
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, DefaultProfileDir))
+	}
+	if profileDir != "" {
+		dirs = append(dirs, profileDir)
+	}
+
+	for _, dir := range dirs {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			// a missing default/optional profile dir is not an error
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			body, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("cannot read profile %s, error: %s", path, err.Error())
+			}
+			var spec externalProfileSpec
+			if err := yaml.Unmarshal(body, &spec); err != nil {
+				return fmt.Errorf("cannot parse profile %s, error: %s", path, err.Error())
+			}
+			if spec.Name == "" || spec.Chart == "" {
+				return fmt.Errorf("profile %s must set both name and chart", path)
+			}
+			RegisterAddonProfile(&externalProfile{spec: spec})
+		}
+	}
+	return nil
+}
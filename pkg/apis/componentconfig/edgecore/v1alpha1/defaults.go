@@ -0,0 +1,45 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+const (
+	// DefaultStaticPodPath is the StaticPodPath NewDefaultEdgeCoreConfig sets.
+	// edged itself applies no default: with an empty StaticPodPath it watches
+	// no directory.
+	DefaultStaticPodPath = "/etc/kubeedge/manifests"
+	// DefaultCGroupDriver is the cgroup driver assumed when none is set.
+	DefaultCGroupDriver = CGroupDriverCGroupFS
+)
+
+// NewDefaultEdgeCoreConfig returns an EdgeCoreConfig populated with the
+// defaults keadm renders into edgecore.yaml before applying any --flag
+// overrides.
+func NewDefaultEdgeCoreConfig() *EdgeCoreConfig {
+	return &EdgeCoreConfig{
+		Modules: &Modules{
+			EdgeHub: &EdgeHub{
+				WebSocket: &EdgeHubWebSocket{},
+				Quic:      &EdgeHubQuic{},
+			},
+			Edged: &Edged{
+				CGroupDriver:  DefaultCGroupDriver,
+				StaticPodPath: DefaultStaticPodPath,
+			},
+			EdgeStream: &EdgeStream{},
+		},
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 holds edgecore's own config types: the file keadm renders
+// to /etc/kubeedge/config/edgecore.yaml and edgecore itself loads on startup.
+package v1alpha1
+
+import corev1 "k8s.io/api/core/v1"
+
+// CGroupDriver is the cgroup driver edged's embedded kubelet talks to the
+// container runtime through.
+type CGroupDriver string
+
+const (
+	CGroupDriverCGroupFS CGroupDriver = "cgroupfs"
+	CGroupDriverSystemd  CGroupDriver = "systemd"
+)
+
+// EdgeCoreConfig is the root of edgecore's config file.
+type EdgeCoreConfig struct {
+	Modules *Modules `json:"modules,omitempty"`
+}
+
+// Modules groups the per-module config blocks edgecore starts.
+type Modules struct {
+	EdgeHub    *EdgeHub    `json:"edgeHub,omitempty"`
+	Edged      *Edged      `json:"edged,omitempty"`
+	EdgeStream *EdgeStream `json:"edgeStream,omitempty"`
+}
+
+// EdgeHub is the config for the module that maintains edgecore's connection
+// to CloudCore.
+type EdgeHub struct {
+	WebSocket *EdgeHubWebSocket `json:"websocket,omitempty"`
+	Quic      *EdgeHubQuic      `json:"quic,omitempty"`
+	// HTTPServer is, per CloudCore endpoint, the HTTPS address edgecore
+	// downloads the CA/node certificates from during registration.
+	HTTPServer []string `json:"httpServer,omitempty"`
+	// Token authenticates edgecore's first registration with CloudCore.
+	Token string `json:"token,omitempty"`
+	// PodFilter restricts which pods this node accepts, mirrored locally from
+	// a ConfigMap; nil (or Enable == false) accepts every pod.
+	PodFilter *PodFilter `json:"podFilter,omitempty"`
+}
+
+// EdgeHubWebSocket is the websocket client config, keyed to the same
+// CloudCore endpoints as Quic and HTTPServer.
+type EdgeHubWebSocket struct {
+	// Server lists the CloudCore websocket endpoints (host:port) edgecore can
+	// dial; more than one enables failover between them.
+	Server []string `json:"server,omitempty"`
+}
+
+// EdgeHubQuic is the quic client config, the websocket transport's
+// alternative.
+type EdgeHubQuic struct {
+	// Server lists the CloudCore quic endpoints (host:port), one per entry in
+	// WebSocket.Server.
+	Server []string `json:"server,omitempty"`
+}
+
+// Edged is the config for the module that runs the embedded kubelet.
+type Edged struct {
+	HostnameOverride      string            `json:"hostnameOverride,omitempty"`
+	NodeIP                string            `json:"nodeIP,omitempty"`
+	RuntimeType           string            `json:"runtimeType,omitempty"`
+	RemoteRuntimeEndpoint string            `json:"remoteRuntimeEndpoint,omitempty"`
+	RemoteImageEndpoint   string            `json:"remoteImageEndpoint,omitempty"`
+	CGroupDriver          CGroupDriver      `json:"cgroupDriver,omitempty"`
+	Taints                []corev1.Taint    `json:"taints,omitempty"`
+	Labels                map[string]string `json:"labels,omitempty"`
+	FeatureGates          map[string]bool   `json:"featureGates,omitempty"`
+	// StaticPodPath is the directory edged watches for static pod manifests,
+	// the same way kubelet's file source works.
+	StaticPodPath string `json:"staticPodPath,omitempty"`
+}
+
+// EdgeStream is the config for the module that tunnels kubectl
+// exec/logs/attach through CloudCore.
+type EdgeStream struct {
+	// TunnelServer lists the CloudCore tunnel endpoints (host:port), one per
+	// entry in EdgeHub.WebSocket.Server.
+	TunnelServer []string `json:"tunnelServer,omitempty"`
+}
+
+// PodFilter backs --pod-filter-config: the allow/deny list is mirrored from
+// ConfigMapNamespace/ConfigMapName into a local file MetaManager's pod filter
+// reloads on change.
+type PodFilter struct {
+	Enable             bool   `json:"enable,omitempty"`
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+	ConfigMapName      string `json:"configMapName,omitempty"`
+}
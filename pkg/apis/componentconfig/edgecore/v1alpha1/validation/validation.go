@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation checks an EdgeCoreConfig is internally consistent before
+// keadm writes it to disk, so a bad flag combination fails at install time
+// instead of on edgecore's next restart.
+package validation
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+// ValidateEdgeCoreConfiguration returns one error per field of c that is
+// missing or inconsistent with the rest of the config.
+func ValidateEdgeCoreConfiguration(c *v1alpha1.EdgeCoreConfig) field.ErrorList {
+	var allErrs field.ErrorList
+
+	modulesPath := field.NewPath("modules")
+	if c.Modules == nil {
+		return append(allErrs, field.Required(modulesPath, "modules must be set"))
+	}
+
+	allErrs = append(allErrs, validateEdgeHub(c.Modules.EdgeHub, modulesPath.Child("edgeHub"))...)
+	allErrs = append(allErrs, validateEdgeStream(c.Modules.EdgeHub, c.Modules.EdgeStream, modulesPath.Child("edgeStream"))...)
+
+	return allErrs
+}
+
+func validateEdgeHub(edgeHub *v1alpha1.EdgeHub, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if edgeHub == nil || edgeHub.WebSocket == nil || len(edgeHub.WebSocket.Server) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("websocket", "server"), "at least one CloudCore endpoint must be set"))
+		return allErrs
+	}
+
+	wantEndpoints := len(edgeHub.WebSocket.Server)
+	if edgeHub.Quic != nil && len(edgeHub.Quic.Server) != 0 && len(edgeHub.Quic.Server) != wantEndpoints {
+		allErrs = append(allErrs, field.Invalid(path.Child("quic", "server"), edgeHub.Quic.Server,
+			"must list exactly one quic endpoint per websocket.server entry"))
+	}
+	if len(edgeHub.HTTPServer) != 0 && len(edgeHub.HTTPServer) != wantEndpoints {
+		allErrs = append(allErrs, field.Invalid(path.Child("httpServer"), edgeHub.HTTPServer,
+			"must list exactly one httpServer entry per websocket.server entry"))
+	}
+
+	if edgeHub.PodFilter != nil && edgeHub.PodFilter.Enable {
+		if edgeHub.PodFilter.ConfigMapNamespace == "" || edgeHub.PodFilter.ConfigMapName == "" {
+			allErrs = append(allErrs, field.Required(path.Child("podFilter"), "configMapNamespace and configMapName must both be set when enabled"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateEdgeStream(edgeHub *v1alpha1.EdgeHub, edgeStream *v1alpha1.EdgeStream, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if edgeStream == nil || edgeHub == nil || edgeHub.WebSocket == nil {
+		return allErrs
+	}
+	if len(edgeStream.TunnelServer) != 0 && len(edgeStream.TunnelServer) != len(edgeHub.WebSocket.Server) {
+		allErrs = append(allErrs, field.Invalid(path.Child("tunnelServer"), edgeStream.TunnelServer,
+			"must list exactly one tunnel endpoint per websocket.server entry"))
+	}
+	return allErrs
+}